@@ -1,3 +1,139 @@
 package cli
 
+import (
+	"fmt"
+	"github.com/rsb/failure"
+	"strings"
+)
+
 type PositionalArgs func(cmd *Cmd, args []string) error
+
+// ArgsError is returned by all the PositionalArgs validators in this package.
+// Path is the full path of the command that rejected the args.
+type ArgsError struct {
+	Path   string
+	Args   []string
+	Reason string
+}
+
+func (e *ArgsError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Reason)
+}
+
+// Unwrap allows failure.IsInvalidParam to identify the error
+func (e *ArgsError) Unwrap() error {
+	return failure.InvalidParam("invalid positional args for (%s)", e.Path)
+}
+
+// NoArgs returns an error if any args are included.
+func NoArgs(cmd *Cmd, args []string) error {
+	if len(args) > 0 {
+		return newArgsError(cmd, args, "unknown command %q", args[0])
+	}
+
+	return nil
+}
+
+// UseArgs returns an error if the args do not match the grammar of the Use
+// line, or if the Use line itself is malformed. It is the validator used when
+// Args is nil, exported so it can be combined with others in MatchAll.
+func UseArgs(cmd *Cmd, args []string) error {
+	g, err := ParseUse(cmd.Use)
+	if err != nil {
+		return err
+	}
+
+	return g.Validator()(cmd, args)
+}
+
+// ArbitraryArgs never returns an error.
+func ArbitraryArgs(cmd *Cmd, args []string) error {
+	return nil
+}
+
+// OnlyValidArgs returns an error if any args are not in the list of
+// ValidArgs or ArgAliases. Descriptions in ValidArgs, separated by a tab,
+// are ignored.
+func OnlyValidArgs(cmd *Cmd, args []string) error {
+	if len(cmd.ValidArgs) == 0 {
+		return nil
+	}
+
+	// Remove any description that may be included in ValidArgs.
+	// A description is following a tab character.
+	var valid []string
+	for _, v := range cmd.ValidArgs {
+		valid = append(valid, strings.Split(v, "\t")[0])
+	}
+	valid = append(valid, cmd.ArgAliases...)
+
+	for _, v := range args {
+		if !stringInSlice(v, valid) {
+			return newArgsError(cmd, args, "invalid argument %q", v)
+		}
+	}
+
+	return nil
+}
+
+// MinimumNArgs returns an error if there is not at least N args.
+func MinimumNArgs(n int) PositionalArgs {
+	return func(cmd *Cmd, args []string) error {
+		if len(args) < n {
+			return newArgsError(cmd, args, "requires at least %d arg(s), only received %d", n, len(args))
+		}
+		return nil
+	}
+}
+
+// MaximumNArgs returns an error if there are more than N args.
+func MaximumNArgs(n int) PositionalArgs {
+	return func(cmd *Cmd, args []string) error {
+		if len(args) > n {
+			return newArgsError(cmd, args, "accepts at most %d arg(s), received %d", n, len(args))
+		}
+		return nil
+	}
+}
+
+// ExactArgs returns an error if there are not exactly n args.
+func ExactArgs(n int) PositionalArgs {
+	return func(cmd *Cmd, args []string) error {
+		if len(args) != n {
+			return newArgsError(cmd, args, "accepts %d arg(s), received %d", n, len(args))
+		}
+		return nil
+	}
+}
+
+// RangeArgs returns an error if the number of args is not within the
+// expected range.
+func RangeArgs(min int, max int) PositionalArgs {
+	return func(cmd *Cmd, args []string) error {
+		if len(args) < min || len(args) > max {
+			return newArgsError(cmd, args, "accepts between %d and %d arg(s), received %d", min, max, len(args))
+		}
+		return nil
+	}
+}
+
+// MatchAll allows combining several PositionalArgs to work in concert.
+// The first error encountered is returned.
+func MatchAll(pargs ...PositionalArgs) PositionalArgs {
+	return func(cmd *Cmd, args []string) error {
+		for _, parg := range pargs {
+			if err := parg(cmd, args); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func newArgsError(cmd *Cmd, args []string, format string, a ...interface{}) error {
+	return &ArgsError{
+		Path:   cmd.Path(),
+		Args:   args,
+		Reason: fmt.Sprintf(format, a...),
+	}
+}
@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"errors"
+	"github.com/rsb/failure"
+	"testing"
+)
+
+func TestPositionalArgs(t *testing.T) {
+	tests := []struct {
+		name   string
+		fn     PositionalArgs
+		args   []string
+		reason string
+	}{
+		{name: "no args", fn: NoArgs, args: []string{}},
+		{name: "no args given one", fn: NoArgs, args: []string{"x"}, reason: `unknown command "x"`},
+		{name: "arbitrary", fn: ArbitraryArgs, args: []string{"x", "y"}},
+		{name: "only valid", fn: OnlyValidArgs, args: []string{"start", "go"}},
+		{name: "only valid given another", fn: OnlyValidArgs, args: []string{"start", "restart"}, reason: `invalid argument "restart"`},
+		{name: "minimum", fn: MinimumNArgs(2), args: []string{"a", "b"}},
+		{name: "below minimum", fn: MinimumNArgs(2), args: []string{"a"}, reason: "requires at least 2 arg(s), only received 1"},
+		{name: "maximum", fn: MaximumNArgs(1), args: []string{"a"}},
+		{name: "above maximum", fn: MaximumNArgs(1), args: []string{"a", "b"}, reason: "accepts at most 1 arg(s), received 2"},
+		{name: "exact", fn: ExactArgs(1), args: []string{"a"}},
+		{name: "not exact", fn: ExactArgs(1), args: []string{}, reason: "accepts 1 arg(s), received 0"},
+		{name: "range", fn: RangeArgs(1, 2), args: []string{"a", "b"}},
+		{name: "out of range", fn: RangeArgs(1, 2), args: []string{"a", "b", "c"}, reason: "accepts between 1 and 2 arg(s), received 3"},
+		{name: "match all", fn: MatchAll(OnlyValidArgs, ExactArgs(1)), args: []string{"stop"}},
+		{name: "match all first error", fn: MatchAll(OnlyValidArgs, ExactArgs(1)), args: []string{"x", "y"}, reason: `invalid argument "x"`},
+		{name: "use", fn: UseArgs, args: []string{"start"}},
+		{name: "not matching use", fn: UseArgs, args: []string{"start", "x"}, reason: "accepts at most 1 arg(s), received 2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := &Cmd{Use: "app"}
+			sub := &Cmd{
+				Use:        "sub {start|stop}",
+				ValidArgs:  []string{"start\tstart it", "stop"},
+				ArgAliases: []string{"go"},
+			}
+			root.Add(sub)
+
+			err := tt.fn(sub, tt.args)
+			if tt.reason == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			var e *ArgsError
+			if !errors.As(err, &e) {
+				t.Fatalf("expected an ArgsError, got %v", err)
+			}
+
+			if e.Path != "app sub" || e.Reason != tt.reason {
+				t.Errorf("error = %+v, want %q from app sub", e, tt.reason)
+			}
+
+			if !failure.IsInvalidParam(err) {
+				t.Error("expected failure.IsInvalidParam to identify the error")
+			}
+		})
+	}
+}
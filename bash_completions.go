@@ -1,5 +1,13 @@
 package cli
 
+import (
+	"fmt"
+	"github.com/rsb/failure"
+	flag "github.com/rsb/pflag"
+	"io"
+	"strings"
+)
+
 // Annotations for Bash completion.
 const (
 	BashCompFilenameExt     = "cli_annotation_bash_completion_filename_extensions"
@@ -7,3 +15,195 @@ const (
 	BashCompOneRequiredFlag = "cli_annotation_bash_completion_one_required_flag"
 	BashCompSubdirsInDir    = "cli_annotation_bash_completion_subdirs_in_dir"
 )
+
+// RequiredFlagsError is returned when flags marked as required were not set.
+// Alternatives holds, by flag name, the other ways a missing flag can be
+// provided.
+type RequiredFlagsError struct {
+	Path         string
+	Missing      []string
+	Alternatives map[string][]string
+}
+
+func (e *RequiredFlagsError) Error() string {
+	var names []string
+	for _, name := range e.Missing {
+		entry := fmt.Sprintf("%q", name)
+		if alts := e.Alternatives[name]; len(alts) > 0 {
+			entry += fmt.Sprintf(" (or %s)", strings.Join(alts, ", "))
+		}
+		names = append(names, entry)
+	}
+
+	return fmt.Sprintf("%s: required flag(s) %s not set", e.Path, strings.Join(names, ", "))
+}
+
+// Unwrap allows failure.IsSystem to identify the error
+func (e *RequiredFlagsError) Unwrap() error {
+	return failure.System("required flag(s) not set for (%s)", e.Path)
+}
+
+// MarkFlagRequired instructs the various shell completion implementations to
+// prioritize the named flag when performing completion,
+// and causes your command to report an error if invoked without the flag.
+func (c *Cmd) MarkFlagRequired(name string) error {
+	return MarkFlagRequired(c.Flags(), name)
+}
+
+// MarkGlobalFlagRequired instructs the various shell completion implementations to
+// prioritize the named global flag when performing completion,
+// and causes your command to report an error if invoked without the flag.
+func (c *Cmd) MarkGlobalFlagRequired(name string) error {
+	return MarkFlagRequired(c.GlobalFlags(), name)
+}
+
+// MarkFlagRequired instructs the various shell completion implementations to
+// prioritize the named flag when performing completion,
+// and causes your command to report an error if invoked without the flag.
+func MarkFlagRequired(flags *flag.FlagSet, name string) error {
+	return flags.SetAnnotation(name, BashCompOneRequiredFlag, []string{"true"})
+}
+
+func isFlagRequired(f *flag.Flag) bool {
+	required, found := f.Annotations[BashCompOneRequiredFlag]
+	return found && len(required) > 0 && required[0] == "true"
+}
+
+// MarkFlagFilename instructs the various shell completion implementations to
+// limit completions for the named flag to the specified file extensions.
+// Extensions are given without the leading dot.
+func (c *Cmd) MarkFlagFilename(name string, extensions ...string) error {
+	return MarkFlagFilename(c.Flags(), name, extensions...)
+}
+
+// MarkGlobalFlagFilename instructs the various shell completion
+// implementations to limit completions for the named global flag to the
+// specified file extensions.
+func (c *Cmd) MarkGlobalFlagFilename(name string, extensions ...string) error {
+	return MarkFlagFilename(c.GlobalFlags(), name, extensions...)
+}
+
+// MarkFlagDirname instructs the various shell completion implementations to
+// limit completions for the named flag to directory names.
+func (c *Cmd) MarkFlagDirname(name string) error {
+	return MarkFlagDirname(c.Flags(), name)
+}
+
+// MarkGlobalFlagDirname instructs the various shell completion
+// implementations to limit completions for the named global flag to
+// directory names.
+func (c *Cmd) MarkGlobalFlagDirname(name string) error {
+	return MarkFlagDirname(c.GlobalFlags(), name)
+}
+
+// MarkFlagFilename instructs the various shell completion implementations to
+// limit completions for the named flag to the specified file extensions.
+func MarkFlagFilename(flags *flag.FlagSet, name string, extensions ...string) error {
+	return flags.SetAnnotation(name, BashCompFilenameExt, extensions)
+}
+
+// MarkFlagDirname instructs the various shell completion implementations to
+// limit completions for the named flag to directory names.
+func MarkFlagDirname(flags *flag.FlagSet, name string) error {
+	return flags.SetAnnotation(name, BashCompSubdirsInDir, []string{})
+}
+
+// GenBashCompletion generates the bash completion script for the root
+// command and writes it to w. The script relies on the bash-completion
+// package and does not show descriptions.
+func (c *Cmd) GenBashCompletion(w io.Writer) error {
+	name := c.Root().Name()
+	_, err := fmt.Fprintf(w, bashCompletionTemplate, name, ShellCompNoDescRequestCmd,
+		ShellCompDirectiveError, ShellCompDirectiveNoSpace, ShellCompDirectiveNoFileComp,
+		ShellCompDirectiveFilterFileExt, ShellCompDirectiveFilterDirs)
+
+	return err
+}
+
+// bashCompletionTemplate is written with fmt, so a % of the script is escaped
+// as %%. The directive is cut from the output with ${out%:*}, which only drops
+// the text after the last colon and keeps the colons within the completions.
+const bashCompletionTemplate = `# bash completion for %-36[1]s -*- shell-script -*-
+
+__%[1]s_debug()
+{
+    if [[ -n ${BASH_COMP_DEBUG_FILE:-} ]]; then
+        echo "$*" >> "${BASH_COMP_DEBUG_FILE}"
+    fi
+}
+
+__start_%[1]s()
+{
+    local cur prev words cword
+    COMPREPLY=()
+    _get_comp_words_by_ref -n "=:" cur prev words cword
+
+    local requestComp out directive
+    requestComp="${words[0]} %[2]s ${words[*]:1}"
+    if [ -z "${cur}" ]; then
+        # The last word is complete, ask for the completions of a new one
+        requestComp="${requestComp} ''"
+    fi
+
+    # When completing a flag with an = only the value is completed
+    if [[ "${cur}" == -*=* ]]; then
+        cur="${cur#*=}"
+    fi
+
+    __%[1]s_debug "Calling ${requestComp}"
+    out=$(eval "${requestComp}" 2>/dev/null)
+
+    # The directive is the integer following the last colon
+    directive=${out##*:}
+    out=${out%%:*}
+    if [ "${directive}" = "${out}" ]; then
+        directive=0
+    fi
+    __%[1]s_debug "The completion directive is: ${directive}"
+
+    if [ $((directive & %[3]d)) -ne 0 ]; then
+        return
+    fi
+
+    if [ $((directive & %[4]d)) -ne 0 ] && [[ $(type -t compopt) = "builtin" ]]; then
+        compopt -o nospace
+    fi
+
+    if [ $((directive & %[5]d)) -ne 0 ] && [[ $(type -t compopt) = "builtin" ]]; then
+        compopt +o default
+    fi
+
+    if [ $((directive & %[6]d)) -ne 0 ]; then
+        # File extension filtering
+        local fullFilter filter
+        for filter in ${out}; do
+            fullFilter+="${filter}|"
+        done
+        _filedir "${fullFilter%%|}"
+        return
+    fi
+
+    if [ $((directive & %[7]d)) -ne 0 ]; then
+        # File completion for directories only
+        local subdir
+        subdir=$(printf "%%s" "${out}")
+        if [ -n "${subdir}" ]; then
+            pushd "${subdir}" >/dev/null 2>&1 && _filedir -d && popd >/dev/null 2>&1 || return
+        else
+            _filedir -d
+        fi
+        return
+    fi
+
+    local IFS=$'\n'
+    COMPREPLY=($(compgen -W "${out}" -- "${cur}"))
+}
+
+if [[ $(type -t compopt) = "builtin" ]]; then
+    complete -o default -F __start_%[1]s %[1]s
+else
+    complete -o default -o nospace -F __start_%[1]s %[1]s
+fi
+
+# ex: ts=4 sw=4 et filetype=sh
+`
@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGenBashCompletion_ColonInCompletion(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash is not installed")
+	}
+
+	root := &Cmd{Use: "app"}
+	script := &bytes.Buffer{}
+	if err := root.GenBashCompletion(script); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// stub what bash-completion provides and the program itself, which
+	// answers with completions holding a colon
+	harness := script.String() + `
+_get_comp_words_by_ref() { cur=""; prev="app"; words=(app ""); cword=1; }
+compopt() { :; }
+app() { printf 'host:8080\nother\n:4\n'; }
+__start_app
+printf '%s\n' "${COMPREPLY[@]}"
+`
+
+	out, err := exec.Command(bash, "-c", harness).Output()
+	if err != nil {
+		t.Fatalf("bash failed: %v", err)
+	}
+
+	got := strings.Fields(string(out))
+	if want := []string{"host:8080", "other"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("COMPREPLY = %q, want %q", got, want)
+	}
+}
+
+func TestRequiredFlagsError(t *testing.T) {
+	root := &Cmd{Use: "app"}
+	root.GlobalFlags().String("region", "", "region")
+	if err := root.MarkGlobalFlagRequired("region"); err != nil {
+		t.Fatal(err)
+	}
+	sub := &Cmd{Use: "sub"}
+	sub.Flags().String("token", "", "token")
+	sub.Flags().String("name", "", "name")
+	sub.Flags().String("zone", "", "zone")
+	sub.SetRun(func(c *Cmd, args []string) error { return nil })
+	root.Add(sub)
+	for _, err := range []error{
+		sub.MarkFlagRequired("token"),
+		sub.MarkFlagSecret("token"),
+		sub.MarkFlagRequired("name"),
+		sub.MarkFlagRequired("zone"),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	root.SetAutomaticEnv("APP")
+	root.SetConfig(Config{Name: "required-flags-test"})
+
+	root.SetOutputStream(&bytes.Buffer{})
+	root.SetErrorStream(&bytes.Buffer{})
+	root.SetArgs([]string{"sub", "--zone", "z"})
+
+	err := root.Execute()
+	var e *RequiredFlagsError
+	if !errors.As(err, &e) {
+		t.Fatalf("expected a RequiredFlagsError, got %v", err)
+	}
+
+	if e.Path != "app sub" || !reflect.DeepEqual(e.Missing, []string{"name", "region", "token"}) {
+		t.Errorf("error = %+v, want name, region and token missing from app sub", e)
+	}
+
+	want := map[string][]string{
+		"name":   {"$APP_NAME", `"sub.name" in config`},
+		"region": {"$APP_REGION", `"sub.region" in config`},
+		"token":  {"--token-stdin", "--token-file", "$APP_TOKEN", `"sub.token" in config`},
+	}
+	if !reflect.DeepEqual(e.Alternatives, want) {
+		t.Errorf("Alternatives = %q, want %q", e.Alternatives, want)
+	}
+
+	if !strings.Contains(e.Error(), `"token" (or --token-stdin, --token-file, $APP_TOKEN, "sub.token" in config)`) {
+		t.Errorf("Error() = %q", e.Error())
+	}
+}
@@ -3,9 +3,12 @@ package cli
 import (
 	"bytes"
 	"context"
+	"errors"
+	"fmt"
 	"github.com/rsb/failure"
 	flag "github.com/rsb/pflag"
 	"io"
+	"os"
 	"sort"
 	"strings"
 )
@@ -41,6 +44,7 @@ type Cmd struct {
 	//   { } delimits a set of mutually exclusive arguments when one of the arguments is required. If the arguments are
 	//       optional, they are enclosed in brackets ([ ]).
 	// Example: add [-F file | -D dir]... [-f format] profile
+	// When Args is nil the positional args are validated against Use, and its choices are completed.
 	Use string
 
 	// Aliases is an array of aliases that can be used instead of the first word in Use.
@@ -82,6 +86,10 @@ type Cmd struct {
 	// Deprecated defines, if this command is deprecated and should print this string when used.
 	Deprecated string
 
+	// Deprecation holds the optional replacement and removal version of a
+	// deprecated command. Setting it also deprecates the command.
+	Deprecation Deprecation
+
 	// Annotations are key/value pairs that can be used by applications to identify or
 	// group commands.
 	Annotations map[string]string
@@ -98,7 +106,8 @@ type Cmd struct {
 	// * Run
 	// * PostRun
 	// * GlobalPostRun
-	// All function have the same run signature CLIRun
+	// All function have the same run signature EventRun and are assigned
+	// with SetLifecycle or the individual Set<Event> methods.
 	lifecycle Lifecycle
 
 	// args is actual args parsed from flags.
@@ -107,6 +116,9 @@ type Cmd struct {
 	// Manage all the pflags
 	flags Flags
 
+	// positional holds the declared positional arguments and their values
+	positional Positional
+
 	// Controls the usage string
 	usage Usage
 
@@ -123,6 +135,40 @@ type Cmd struct {
 	// input, output and error streams
 	streams Streams
 
+	// env configures how flags are read from environment variables
+	env Env
+
+	// config configures the config files providing flag values
+	config *Config
+
+	// origins records where the value of each flag came from
+	origins map[string]FlagOrigin
+
+	// flagWarningPolicy determines what happens to flag warnings
+	flagWarningPolicy FlagWarningPolicy
+
+	// flagWarnings raised while setting the flags, like deprecated flags
+	flagWarnings []string
+
+	// flagRenames maps the old names of renamed flags to their new names
+	flagRenames map[string]string
+
+	// unknownFlags let through by FParseErrWhitelist during the last parse
+	// and the number of positional args given before each of them
+	unknownFlags   []string
+	unknownFlagsAt []int
+
+	// flagRules attached to the flags of the command by AddFlagRules
+	flagRules map[string][]FlagRule
+
+	// explainFlags is set by EnableExplainFlags
+	explainFlags bool
+
+	// verbose and quiet are counted by the flags of EnableVerbosityFlags
+	verbosityFlags bool
+	verbose        int
+	quiet          int
+
 	// FParseErrWhitelist flag parse errors to be ignored
 	FParseErrWhitelist FParseErrWhitelist
 
@@ -214,13 +260,63 @@ func (c *Cmd) SetArgs(a []string) {
 	c.args = a
 }
 
+// InputStream returns the assigned stdin, falling back to the parent's
+func (c *Cmd) InputStream() io.Reader {
+	if c.streams.in == nil && c.HasParent() {
+		return c.parent.InputStream()
+	}
+
+	return c.streams.In()
+}
+
+// Lifecycle returns the events assigned to the command.
+func (c *Cmd) Lifecycle() Lifecycle {
+	return c.lifecycle
+}
+
+// SetLifecycle replaces all the events of the command at once.
+func (c *Cmd) SetLifecycle(l Lifecycle) {
+	c.lifecycle = l
+}
+
+// SetGlobalPreRun assigns the event run before PreRun. Children inherit it
+// when they do not declare their own.
+func (c *Cmd) SetGlobalPreRun(fn EventRun) {
+	c.lifecycle.GlobalPreRun = fn
+}
+
+// SetPreRun assigns the event run before Run.
+func (c *Cmd) SetPreRun(fn EventRun) {
+	c.lifecycle.PreRun = fn
+}
+
+// SetRun assigns the main event of the command, making it runnable.
+func (c *Cmd) SetRun(fn EventRun) {
+	c.lifecycle.Run = fn
+}
+
+// SetPostRun assigns the event run after Run.
+func (c *Cmd) SetPostRun(fn EventRun) {
+	c.lifecycle.PostRun = fn
+}
+
+// SetGlobalPostRun assigns the event run after PostRun. Children inherit it
+// when they do not declare their own.
+func (c *Cmd) SetGlobalPostRun(fn EventRun) {
+	c.lifecycle.GlobalPostRun = fn
+}
+
 // SetInputStream allows the input stream to be assigned to the command.
 func (c *Cmd) SetInputStream(in io.Reader) {
 	c.streams.SetIn(in)
 }
 
-// OutputStream returns the assign stdout
+// OutputStream returns the assign stdout, falling back to the parent's
 func (c *Cmd) OutputStream() io.Writer {
+	if c.streams.out == nil && c.HasParent() {
+		return c.parent.OutputStream()
+	}
+
 	return c.streams.Out()
 }
 
@@ -229,8 +325,12 @@ func (c *Cmd) SetOutputStream(out io.Writer) {
 	c.streams.SetOut(out)
 }
 
-// ErrorStream returns the assign stderr
+// ErrorStream returns the assign stderr, falling back to the parent's
 func (c *Cmd) ErrorStream() io.Writer {
+	if c.streams.err == nil && c.HasParent() {
+		return c.parent.ErrorStream()
+	}
+
 	return c.streams.Error()
 }
 
@@ -352,14 +452,94 @@ func (c *Cmd) GlobalFlags() *flag.FlagSet {
 // LocalSpecificFlags are flags specific to this command which will NOT
 // persist to subcommands.
 func (c *Cmd) LocalSpecificFlags() *flag.FlagSet {
-	return nil
+	global := c.GlobalFlags()
+
+	out := newFlagSet(c.Name())
+	out.SetOutput(c.flags.LoadErrorBufferWhenEmpty())
+	out.SortFlags = c.Flags().SortFlags
+	if c.flags.IsGlobalNormalizeFn() {
+		out.SetNormalizeFunc(c.flags.GlobalNormalizeFn)
+	}
+
+	c.LocalFlags().VisitAll(func(f *flag.Flag) {
+		if global.Lookup(f.Name) == nil {
+			out.AddFlag(f)
+		}
+	})
+
+	return out
 }
 
-// LocalFlags returns the local FlagSet specifically set in the current command.
+// LocalFlags returns the local FlagSet specifically set in the current
+// command. This is the full set minus the global flags of the parents.
 func (c *Cmd) LocalFlags() *flag.FlagSet {
-	return nil
+	c.mergeGlobalFlags()
+
+	if !c.flags.IsLocal() {
+		c.flags.LoadLocalSet(c.Name())
+	}
+
+	local := c.flags.Local
+	local.SortFlags = c.Flags().SortFlags
+	if c.flags.IsGlobalNormalizeFn() {
+		local.SetNormalizeFunc(c.flags.GlobalNormalizeFn)
+	}
+
+	addToLocal := func(f *flag.Flag) {
+		// Add the flag if it is not a parent global, or it shadows one
+		if local.Lookup(f.Name) == nil && f != c.flags.ParentsGlobal.Lookup(f.Name) {
+			local.AddFlag(f)
+		}
+	}
+	c.Flags().VisitAll(addToLocal)
+	c.GlobalFlags().VisitAll(addToLocal)
+
+	return local
+}
+
+// InheritedFlags returns all flags which were inherited from the global
+// flags of the parents.
+func (c *Cmd) InheritedFlags() *flag.FlagSet {
+	c.mergeGlobalFlags()
+
+	if !c.flags.IsInherited() {
+		c.flags.LoadInheritedSet(c.Name())
+	}
+
+	inherited := c.flags.Inherited
+	local := c.LocalFlags()
+	if c.flags.IsGlobalNormalizeFn() {
+		inherited.SetNormalizeFunc(c.flags.GlobalNormalizeFn)
+	}
+
+	c.flags.ParentsGlobal.VisitAll(func(f *flag.Flag) {
+		if inherited.Lookup(f.Name) == nil && local.Lookup(f.Name) == nil {
+			inherited.AddFlag(f)
+		}
+	})
+
+	return inherited
+}
+
+// HasAvailableLocalFlags determines if the command has local flags which are
+// not hidden.
+func (c *Cmd) HasAvailableLocalFlags() bool {
+	return c.LocalFlags().HasAvailableFlags()
+}
+
+// HasAvailableInheritedFlags determines if the command has inherited flags
+// which are not hidden.
+func (c *Cmd) HasAvailableInheritedFlags() bool {
+	return c.InheritedFlags().HasAvailableFlags()
+}
+
+// SetFlagErrorClosure assigns the closure called when flag parsing fails
+func (c *Cmd) SetFlagErrorClosure(fn ControlFlagErrorFn) {
+	c.flagErrorFn = fn
 }
 
+// FlagErrorFn returns the closure used to handle flag parse errors, walking
+// up the parents until one is found.
 func (c *Cmd) FlagErrorFn() ControlFlagErrorFn {
 	if c.flagErrorFn != nil {
 		return c.flagErrorFn
@@ -378,30 +558,73 @@ func (c *Cmd) ParseFlags(args []string) error {
 		return nil
 	}
 
-	errorBuf := c.flags.LoadErrorBufferWhenEmpty()
-	beforeErrorLen := errorBuf.Len()
-
 	c.mergeGlobalFlags()
 
 	// do it here after merging all the flags and just before parse
 	c.Flags().ParseErrorsWhitelist = flag.ParseErrorsWhitelist(c.FParseErrWhitelist)
 
-	err := c.Flags().Parse(args)
-	// Print warnings if they occurred (e.g. deprecated flag messages).
-	if errorBuf.Len()-beforeErrorLen > 0 && err == nil {
-		// c.Print(errorBuf.String())
+	args = c.renameFlagArgs(args)
+
+	// The parser drops the unknown flags it is allowed to ignore, set them
+	// aside so they reach the args
+	c.unknownFlags, c.unknownFlagsAt = nil, nil
+	if c.FParseErrWhitelist.UnknownFlags {
+		args = c.extractUnknownFlags(args)
 	}
+
+	// the values of secrets are left out of the errors of the flags
+	flags := c.Flags()
+	err := flags.ParseAll(args, func(f *flag.Flag, value string) error {
+		return redactFlagError(f, flags.Set(f.Name, value))
+	})
+
+	// Keep the warnings (e.g. deprecated flag messages), they are reported
+	// once all the flag values have been set.
+	c.collectFlagWarnings()
 	return err
 }
 
+// IsRunnable determines if the command has a Run event to execute
+func (c *Cmd) IsRunnable() bool {
+	return c.lifecycle.IsRunnable()
+}
+
+// HasAvailableSubCommands determines if the command has children which are
+// not hidden and can be executed.
+func (c *Cmd) HasAvailableSubCommands() bool {
+	for _, sub := range c.commands {
+		if sub.IsAvailableCommand() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsAvailableCommand determines if the command should be listed in help
+func (c *Cmd) IsAvailableCommand() bool {
+	if c.Hidden || c.IsDeprecated() {
+		return false
+	}
+
+	return c.IsRunnable() || c.HasAvailableSubCommands()
+}
+
 func (c *Cmd) HasAvailableFlags() bool {
 	return c.Flags().HasAvailableFlags()
 }
 
+// UseLine returns the full usage line of the command. When positional args
+// are declared they replace everything after the name in Use.
 func (c *Cmd) UseLine() string {
-	line := c.Use
+	use := c.Use
+	if c.HasDeclaredArgs() {
+		use = c.Name() + " " + c.ArgsUseLine()
+	}
+
+	line := use
 	if c.HasParent() {
-		line = c.parent.Path() + " " + c.Use
+		line = c.parent.Path() + " " + use
 	}
 
 	if c.DisableFlagsInUseLine {
@@ -425,9 +648,18 @@ func (c *Cmd) GlobalNormalization() GlobalNormalizeFlagFn {
 	return c.flags.GlobalNormalizeFn
 }
 
-// SetGlobalNormalization assigns the closure to the command
+// SetGlobalNormalization assigns the closure to the flag sets of the command
+// and of every subcommand, including the ones added before it was set.
+// NormalizeDashes, NormalizeCase and NormalizeRenames are ready made
+// closures, combined with ChainNormalizers.
 func (c *Cmd) SetGlobalNormalization(fn GlobalNormalizeFlagFn) {
 	c.flags.GlobalNormalizeFn = fn
+	c.Flags().SetNormalizeFunc(fn)
+	c.GlobalFlags().SetNormalizeFunc(fn)
+
+	for _, cmd := range c.commands {
+		cmd.SetGlobalNormalization(fn)
+	}
 }
 
 // Add assigns on or more commands to this parent command
@@ -463,6 +695,7 @@ MAIN:
 		}
 		commands = append(commands, command)
 	}
+	c.commands = commands
 
 	// recompute all lengths
 	c.resetMaxLengths()
@@ -471,6 +704,419 @@ MAIN:
 	}
 }
 
+// Execute uses the args (os.Args[1:] by default) and runs through the command
+// tree finding appropriate matches for commands and then corresponding flags.
+func (c *Cmd) Execute() error {
+	_, err := c.ExecuteC()
+	return err
+}
+
+// ExecuteContext is the same as Execute, but sets the ctx on the command.
+// Retrieve ctx by calling cmd.Context() inside your lifecycle events.
+func (c *Cmd) ExecuteContext(ctx context.Context) error {
+	c.ctx = ctx
+	return c.Execute()
+}
+
+// ExecuteContextC is the same as ExecuteC, but sets the ctx on the command.
+func (c *Cmd) ExecuteContextC(ctx context.Context) (*Cmd, error) {
+	c.ctx = ctx
+	return c.ExecuteC()
+}
+
+// ExecuteC executes the command tree from the root and returns the command
+// that was resolved from the args.
+func (c *Cmd) ExecuteC() (*Cmd, error) {
+	if c.ctx == nil {
+		c.ctx = context.Background()
+	}
+
+	// Regardless of what command execute is called on, run on Root only
+	if c.HasParent() {
+		return c.Root().ExecuteC()
+	}
+
+	args := c.args
+	if c.args == nil {
+		args = os.Args[1:]
+	}
+
+	if err := c.ValidateTree(); err != nil {
+		return c, err
+	}
+
+	c.InitDefaultExplainFlag()
+	c.initVerbosityFlags()
+
+	// the counts of --verbose and --quiet only hold for this execution, they
+	// are reset before any command of the tree parses its flags
+	c.verbose, c.quiet = 0, 0
+
+	// initialize the default completion command which generates the shell
+	// scripts and the hidden command they use to request completions
+	c.initDefaultCompletionCmd()
+	c.initCompleteCmd(args)
+
+	var flags []string
+	var cmd *Cmd
+	var err error
+	if c.TraverseChildren {
+		cmd, flags, err = c.Traverse(args)
+	} else {
+		cmd, flags, err = c.Find(args)
+	}
+	if err == nil {
+		cmd, err = cmd.forwardDeprecated()
+	}
+	if err != nil {
+		// If found parse to a subcommand and then failed, talk about the subcommand
+		if cmd != nil {
+			c = cmd
+		}
+		if !c.SilenceErrors {
+			c.PrintErrln("[Error]:", err.Error())
+			c.PrintErrf("Run '%v --help' for usage.\n", c.Path())
+		}
+		return c, err
+	}
+
+	// We have to pass global context to children command
+	// if context is present on the parent command.
+	if cmd.ctx == nil {
+		cmd.ctx = c.ctx
+	}
+
+	err = cmd.execute(flags)
+	if err != nil {
+		// Always show help if requested, even if SilenceErrors is in effect
+		if errors.Is(err, flag.ErrHelp) {
+			cmd.HelpFn()(cmd, args)
+			return cmd, nil
+		}
+
+		// If root command has SilenceErrors flagged,
+		// all subcommands are automatically silenced
+		if !cmd.SilenceErrors && !c.SilenceErrors {
+			c.PrintErrln("[Error]:", err.Error())
+		}
+
+		// If root command has SilenceUsage flagged,
+		// all subcommands are automatically silenced
+		if !cmd.SilenceUsage && !c.SilenceUsage {
+			c.Println(cmd.UsageString())
+		}
+	}
+
+	return cmd, err
+}
+
+// Find the target command given the args and command tree. Flags are
+// stripped from the args to walk the subcommands and the remaining args,
+// flags included, are returned for the leaf command to parse. When a root, or
+// a group which is not runnable, without Args is given an unknown subcommand
+// an UnknownCommandError is returned.
+func (c *Cmd) Find(args []string) (*Cmd, []string, error) {
+	var inner func(*Cmd, []string) (*Cmd, []string)
+	inner = func(c *Cmd, args []string) (*Cmd, []string) {
+		argsWithoutFlags := stripFlags(args, c)
+		if len(argsWithoutFlags) == 0 {
+			return c, args
+		}
+
+		next := argsWithoutFlags[0]
+		cmd := c.findNext(next)
+		if cmd != nil {
+			return inner(cmd, argsMinusFirstX(args, next))
+		}
+
+		return c, args
+	}
+
+	cmd, a := inner(c, args)
+	cmd.markCalled()
+
+	if cmd.Args == nil {
+		return cmd, a, legacyArgs(cmd, stripFlags(a, cmd))
+	}
+
+	return cmd, a, nil
+}
+
+// Traverse the command tree to find the command, and parse args for
+// each parent as it descends. This is used when TraverseChildren is set.
+// Unknown subcommands are reported the same way as in Find.
+func (c *Cmd) Traverse(args []string) (*Cmd, []string, error) {
+	var flags []string
+	inFlag := false
+
+	for i, arg := range args {
+		switch {
+		// A long flag with a space separated value
+		case strings.HasPrefix(arg, "--") && !strings.Contains(arg, "="):
+			inFlag = !hasNoOptDefVal(arg[2:], c.Flags())
+			flags = append(flags, arg)
+			continue
+		// A short flag with a space separated value
+		case strings.HasPrefix(arg, "-") && !strings.Contains(arg, "=") && len(arg) == 2 && !shortHasNoOptDefVal(arg[1:], c.Flags()):
+			inFlag = true
+			flags = append(flags, arg)
+			continue
+		// The value for a flag
+		case inFlag:
+			inFlag = false
+			flags = append(flags, arg)
+			continue
+		// A flag without a value, or with an `=` separated value
+		case isFlagArg(arg):
+			flags = append(flags, arg)
+			continue
+		}
+
+		cmd := c.findNext(arg)
+		if cmd == nil {
+			c.markCalled()
+			if c.Args == nil {
+				return c, args, legacyArgs(c, args[i:i+1])
+			}
+			return c, args, nil
+		}
+
+		if err := c.ParseFlags(flags); err != nil {
+			return nil, args, err
+		}
+
+		return cmd.Traverse(args[i+1:])
+	}
+
+	c.markCalled()
+	return c, args, nil
+}
+
+// CalledAs returns the command name or alias that was used to invoke
+// this command or an empty string if the command has not been called.
+func (c *Cmd) CalledAs() string {
+	if c.calledAs.IsCalled {
+		return c.calledAs.Name
+	}
+
+	return ""
+}
+
+// ValidateArgs runs the Args validator against the positional args. When
+// Args is nil the validator is derived from the Use line, unless the Use line
+// only holds the name, positional args are declared or flag parsing is
+// disabled.
+func (c *Cmd) ValidateArgs(args []string) error {
+	if c.Args != nil {
+		return c.Args(c, args)
+	}
+
+	if c.HasDeclaredArgs() || c.DisableFlagParsing {
+		return nil
+	}
+
+	g, err := ParseUse(c.Use)
+	if err != nil {
+		return err
+	}
+
+	if !g.HasArgs() {
+		return nil
+	}
+
+	return g.Validator()(c, args)
+}
+
+// ValidateTree checks the Use line of this command and of all its
+// subcommands, returning every malformed one at once in a failure.Multi.
+// It is called by ExecuteC so a broken tree fails before any command runs.
+func (c *Cmd) ValidateTree() error {
+	var errs *failure.Multi
+	var visit func(x *Cmd)
+	visit = func(x *Cmd) {
+		if _, err := ParseUse(x.Use); err != nil {
+			errs = failure.Append(errs, failure.Wrap(err, "command (%s)", x.Path()))
+		}
+
+		for _, sub := range x.commands {
+			visit(sub)
+		}
+	}
+	visit(c)
+
+	return errs.ErrorOrNil()
+}
+
+// Print is a convenience method to Print to the defined output
+func (c *Cmd) Print(i ...interface{}) {
+	_, _ = fmt.Fprint(c.OutputStream(), i...)
+}
+
+// Println is a convenience method to Println to the defined output
+func (c *Cmd) Println(i ...interface{}) {
+	c.Print(fmt.Sprintln(i...))
+}
+
+// Printf is a convenience method to Printf to the defined output
+func (c *Cmd) Printf(format string, i ...interface{}) {
+	c.Print(fmt.Sprintf(format, i...))
+}
+
+// PrintErr is a convenience method to Print to the defined error stream
+func (c *Cmd) PrintErr(i ...interface{}) {
+	_, _ = fmt.Fprint(c.ErrorStream(), i...)
+}
+
+// PrintErrln is a convenience method to Println to the defined error stream
+func (c *Cmd) PrintErrln(i ...interface{}) {
+	c.PrintErr(fmt.Sprintln(i...))
+}
+
+// PrintErrf is a convenience method to Printf to the defined error stream
+func (c *Cmd) PrintErrf(format string, i ...interface{}) {
+	c.PrintErr(fmt.Sprintf(format, i...))
+}
+
+// execute parses the flags for this command, validates them along with the
+// positional args and then fires the lifecycle events in order.
+func (c *Cmd) execute(a []string) error {
+	if c == nil {
+		return failure.System("called execute() on a nil Cmd")
+	}
+
+	if c.IsDeprecated() {
+		c.PrintErrln(c.DeprecationNotice())
+	}
+
+	// initialize help and version flag at the last point possible to allow for user
+	// overriding
+	c.InitDefaultHelpFlag()
+	c.InitDefaultVersionFlag()
+
+	c.flagWarnings = nil
+	if err := c.ParseFlags(a); err != nil {
+		return c.FlagErrorFn()(c, c.flagSuggestionsError(err, a))
+	}
+
+	// If help is called, regardless of other flags, return we want help.
+	// Also say we need help if the command isn't available.
+	helpVal, err := c.Flags().GetBool("help")
+	if err != nil {
+		// should be impossible to get here as we always declare a help
+		// flag in InitDefaultHelpFlag()
+		c.Println(`"help" flag declared as non-bool. Please correct your code`)
+		return err
+	}
+
+	if helpVal {
+		return flag.ErrHelp
+	}
+
+	// for back-compat, only add version flag behavior if version is defined
+	if c.Version != "" {
+		versionVal, err := c.Flags().GetBool("version")
+		if err != nil {
+			c.Println(`"version" flag declared as non-bool. Please correct your code`)
+			return err
+		}
+		if versionVal {
+			if err := tpl(c.OutputStream(), c.VersionTemplate(), c); err != nil {
+				c.Println(err)
+			}
+			return nil
+		}
+	}
+
+	if !c.IsRunnable() && !c.isExplainingFlags() {
+		return flag.ErrHelp
+	}
+
+	c.resetFlagOrigins()
+	if err := c.applyFlagEnv(); err != nil {
+		return c.FlagErrorFn()(c, err)
+	}
+
+	if err := c.applyConfig(); err != nil {
+		return c.FlagErrorFn()(c, err)
+	}
+
+	if err := c.applySecretFlags(); err != nil {
+		return c.FlagErrorFn()(c, err)
+	}
+
+	if err := c.reportFlagWarnings(); err != nil {
+		return c.FlagErrorFn()(c, err)
+	}
+
+	if c.isExplainingFlags() {
+		c.Print(c.ExplainFlags())
+		return nil
+	}
+
+	// unknown flags let through by FParseErrWhitelist are only given to the
+	// Lifecycle, they are not positional args
+	positionalArgs := c.Flags().Args()
+	argWoFlags := c.argsWithUnknownFlags(positionalArgs)
+	if c.DisableFlagParsing {
+		positionalArgs, argWoFlags = a, a
+	}
+	if err := c.ValidateArgs(positionalArgs); err != nil {
+		return err
+	}
+
+	if err := c.parseDeclaredArgs(positionalArgs); err != nil {
+		return err
+	}
+
+	if err := c.validateRequiredFlags(); err != nil {
+		return err
+	}
+
+	if err := c.ValidateFlagGroups(); err != nil {
+		return err
+	}
+
+	if err := c.ValidateFlagRules(); err != nil {
+		return err
+	}
+
+	for p := c; p != nil; p = p.Parent() {
+		if p.lifecycle.GlobalPreRun != nil {
+			if err := p.lifecycle.GlobalPreRun(c, argWoFlags); err != nil {
+				return err
+			}
+			break
+		}
+	}
+
+	if c.lifecycle.PreRun != nil {
+		if err := c.lifecycle.PreRun(c, argWoFlags); err != nil {
+			return err
+		}
+	}
+
+	if err := c.lifecycle.Run(c, argWoFlags); err != nil {
+		return err
+	}
+
+	if c.lifecycle.PostRun != nil {
+		if err := c.lifecycle.PostRun(c, argWoFlags); err != nil {
+			return err
+		}
+	}
+
+	for p := c; p != nil; p = p.Parent() {
+		if p.lifecycle.GlobalPostRun != nil {
+			if err := p.lifecycle.GlobalPostRun(c, argWoFlags); err != nil {
+				return err
+			}
+			break
+		}
+	}
+
+	return nil
+}
+
 // mergeGlobalFlags merges c.flags.Global into c.flags.Full
 // and adds missing global flags to all parents.
 func (c *Cmd) mergeGlobalFlags() {
@@ -547,6 +1193,15 @@ func (c *Cmd) findNext(next string) *Cmd {
 	return nil
 }
 
+// markCalled records that this command was resolved from the args. The
+// root is never matched by findNext so it falls back to its name.
+func (c *Cmd) markCalled() {
+	c.calledAs.IsCalled = true
+	if c.calledAs.Name == "" {
+		c.calledAs.Name = c.Name()
+	}
+}
+
 func (c *Cmd) validateRequiredFlags() error {
 	if c.DisableFlagParsing {
 		return nil
@@ -555,17 +1210,32 @@ func (c *Cmd) validateRequiredFlags() error {
 	flags := c.Flags()
 	var missing []string
 	flags.VisitAll(func(pflag *flag.Flag) {
-		requiredAnnotation, found := pflag.Annotations[BashCompOneRequiredFlag]
-		if !found {
-			return
-		}
-		if (requiredAnnotation[0] == "true") && !pflag.Changed {
+		if isFlagRequired(pflag) && !pflag.Changed {
 			missing = append(missing, pflag.Name)
 		}
 	})
 
 	if len(missing) > 0 {
-		return failure.System(`required flag(s) "%s" not set`, strings.Join(missing, `","`))
+		alternatives := map[string][]string{}
+		for _, name := range missing {
+			if isFlagSecret(flags.Lookup(name)) {
+				alternatives[name] = append(alternatives[name], "--"+name+"-stdin", "--"+name+"-file")
+			}
+
+			for _, v := range c.flagEnvVars(flags.Lookup(name)) {
+				alternatives[name] = append(alternatives[name], "$"+v)
+			}
+
+			if keys := c.ConfigKeys(name); c.Root().config != nil && len(keys) > 0 {
+				alternatives[name] = append(alternatives[name], fmt.Sprintf("%q in config", keys[0]))
+			}
+		}
+
+		return &RequiredFlagsError{
+			Path:         c.Path(),
+			Missing:      missing,
+			Alternatives: alternatives,
+		}
 	}
 
 	return nil
@@ -604,7 +1274,7 @@ type MaxLengths struct {
 }
 
 // Reset reverts all lengths to their default values
-func (ml MaxLengths) Reset() {
+func (ml *MaxLengths) Reset() {
 	ml.Use = 0
 	ml.Path = 0
 	ml.Name = 0
@@ -622,7 +1292,8 @@ type EventRun func(*Cmd, []string) error
 // * Run
 // * PostRun
 // * GlobalPostRun
-// All events follow the same function signature.
+// All events follow the same function signature. GlobalPreRun and
+// GlobalPostRun are inherited from the nearest parent that declares them.
 type Lifecycle struct {
 	GlobalPreRun  EventRun
 	PreRun        EventRun
@@ -653,7 +1324,7 @@ func (f *Flags) ClearParentsGlobal() {
 }
 
 func (f *Flags) IsParentsGlobalFlags() bool {
-	return f.ParentsGlobal == nil
+	return f.ParentsGlobal != nil
 }
 
 func (f *Flags) LoadParentsGlobal(name string) {
@@ -684,7 +1355,7 @@ func (f *Flags) LoadErrorBuffer() {
 }
 
 func (f *Flags) IsFull() bool {
-	return f.Global != nil
+	return f.Full != nil
 }
 
 func (f *Flags) LoadFullSet(name string) {
@@ -692,6 +1363,24 @@ func (f *Flags) LoadFullSet(name string) {
 	f.Full.SetOutput(f.LoadErrorBufferWhenEmpty())
 }
 
+func (f *Flags) IsLocal() bool {
+	return f.Local != nil
+}
+
+func (f *Flags) LoadLocalSet(name string) {
+	f.Local = newFlagSet(name)
+	f.Local.SetOutput(f.LoadErrorBufferWhenEmpty())
+}
+
+func (f *Flags) IsInherited() bool {
+	return f.Inherited != nil
+}
+
+func (f *Flags) LoadInheritedSet(name string) {
+	f.Inherited = newFlagSet(name)
+	f.Inherited.SetOutput(f.LoadErrorBufferWhenEmpty())
+}
+
 func (f *Flags) IsGlobal() bool {
 	return f.Global != nil
 }
@@ -0,0 +1,244 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// recordEvent returns an EventRun appending name to events
+func recordEvent(events *[]string, name string) EventRun {
+	return func(c *Cmd, args []string) error {
+		*events = append(*events, name)
+		return nil
+	}
+}
+
+func TestExecute_Lifecycle(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(root, sub *Cmd, events *[]string)
+		want  []string
+		err   bool
+	}{
+		{
+			name: "inherited global events",
+			setup: func(root, sub *Cmd, events *[]string) {
+				root.SetGlobalPreRun(recordEvent(events, "root GlobalPreRun"))
+				root.SetGlobalPostRun(recordEvent(events, "root GlobalPostRun"))
+				sub.SetPreRun(recordEvent(events, "PreRun"))
+				sub.SetPostRun(recordEvent(events, "PostRun"))
+			},
+			want: []string{"root GlobalPreRun", "PreRun", "Run", "PostRun", "root GlobalPostRun"},
+		},
+		{
+			name: "closest global events win",
+			setup: func(root, sub *Cmd, events *[]string) {
+				root.SetGlobalPreRun(recordEvent(events, "root GlobalPreRun"))
+				root.SetGlobalPostRun(recordEvent(events, "root GlobalPostRun"))
+				sub.SetGlobalPreRun(recordEvent(events, "sub GlobalPreRun"))
+				sub.SetGlobalPostRun(recordEvent(events, "sub GlobalPostRun"))
+			},
+			want: []string{"sub GlobalPreRun", "Run", "sub GlobalPostRun"},
+		},
+		{
+			name: "failing event stops the lifecycle",
+			setup: func(root, sub *Cmd, events *[]string) {
+				root.SetGlobalPreRun(recordEvent(events, "root GlobalPreRun"))
+				sub.SetPreRun(func(c *Cmd, args []string) error {
+					*events = append(*events, "PreRun")
+					return errors.New("pre run failed")
+				})
+				sub.SetPostRun(recordEvent(events, "PostRun"))
+			},
+			want: []string{"root GlobalPreRun", "PreRun"},
+			err:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var events []string
+			root := &Cmd{Use: "app"}
+			sub := &Cmd{Use: "sub"}
+			sub.SetRun(recordEvent(&events, "Run"))
+			root.Add(sub)
+			tt.setup(root, sub, &events)
+
+			root.SetOutputStream(&bytes.Buffer{})
+			root.SetErrorStream(&bytes.Buffer{})
+			root.SetArgs([]string{"sub"})
+
+			err := root.Execute()
+			if tt.err != (err != nil) {
+				t.Fatalf("Execute() = %v, want error %v", err, tt.err)
+			}
+
+			if !reflect.DeepEqual(events, tt.want) {
+				t.Errorf("events = %q, want %q", events, tt.want)
+			}
+		})
+	}
+}
+
+func TestExecute_HelpAndVersion(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{name: "help flag", args: []string{"remote", "--help"}, want: "Usage:"},
+		{name: "not runnable", args: []string{"remote"}, want: "Usage:"},
+		{name: "help flag on runnable", args: []string{"remote", "add", "-h"}, want: "Usage:"},
+		{name: "version flag", args: []string{"--version"}, want: "app version 1.2.3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ran bool
+			root := &Cmd{Use: "app", Version: "1.2.3"}
+			remote := &Cmd{Use: "remote"}
+			add := &Cmd{Use: "add"}
+			add.SetRun(func(c *Cmd, args []string) error {
+				ran = true
+				return nil
+			})
+			remote.Add(add)
+			root.Add(remote)
+
+			out := &bytes.Buffer{}
+			root.SetOutputStream(out)
+			root.SetErrorStream(&bytes.Buffer{})
+			root.SetArgs(tt.args)
+
+			if err := root.Execute(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if ran {
+				t.Error("expected the command not to run")
+			}
+
+			if !strings.Contains(out.String(), tt.want) {
+				t.Errorf("output = %q, want it to contain %q", out.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestExecuteContext(t *testing.T) {
+	type key struct{}
+	var got interface{}
+	root := &Cmd{Use: "app"}
+	sub := &Cmd{Use: "sub"}
+	sub.SetRun(func(c *Cmd, args []string) error {
+		got = c.Context().Value(key{})
+		return nil
+	})
+	root.Add(sub)
+	root.SetOutputStream(&bytes.Buffer{})
+	root.SetErrorStream(&bytes.Buffer{})
+	root.SetArgs([]string{"sub"})
+
+	ctx := context.WithValue(context.Background(), key{}, "value")
+	if err := root.ExecuteContext(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got != "value" {
+		t.Errorf("context value = %v, want the context given to the root", got)
+	}
+}
+
+// newFindTree builds app, with a global --debug and a local --name, holding
+// remote, aliased rm, holding add
+func newFindTree() (root, remote, add *Cmd) {
+	root = &Cmd{Use: "app"}
+	root.GlobalFlags().Bool("debug", false, "debug")
+	root.Flags().String("name", "", "name")
+	remote = &Cmd{Use: "remote", Aliases: []string{"rm"}}
+	remote.GlobalFlags().StringP("url", "u", "", "url")
+	add = &Cmd{Use: "add"}
+	add.SetRun(func(c *Cmd, args []string) error { return nil })
+	remote.Add(add)
+	root.Add(remote)
+
+	return root, remote, add
+}
+
+func TestFind(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		want     string
+		calledAs string
+		rest     []string
+	}{
+		{name: "leaf", args: []string{"remote", "add", "x"}, want: "app remote add", calledAs: "add", rest: []string{"x"}},
+		{name: "flags before the subcommand", args: []string{"--debug", "remote", "-u", "host", "add", "x"}, want: "app remote add", calledAs: "add", rest: []string{"--debug", "-u", "host", "x"}},
+		{name: "alias", args: []string{"rm", "add"}, want: "app remote add", calledAs: "add"},
+		{name: "alias of the leaf", args: []string{"rm"}, want: "app remote", calledAs: "rm"},
+		{name: "root", args: []string{"--debug"}, want: "app", calledAs: "app", rest: []string{"--debug"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, _, _ := newFindTree()
+			cmd, rest, err := root.Find(tt.args)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if cmd.Path() != tt.want || cmd.CalledAs() != tt.calledAs {
+				t.Errorf("Find(%q) = %q called as %q, want %q called as %q", tt.args, cmd.Path(), cmd.CalledAs(), tt.want, tt.calledAs)
+			}
+
+			if !reflect.DeepEqual(rest, tt.rest) {
+				t.Errorf("Find(%q) args = %q, want %q", tt.args, rest, tt.rest)
+			}
+		})
+	}
+}
+
+func TestCalledAs_NotCalled(t *testing.T) {
+	root, remote, _ := newFindTree()
+	if _, _, err := root.Find([]string{"remote", "add"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if remote.CalledAs() != "" {
+		t.Errorf("CalledAs() = %q, want nothing for a command which was walked through", remote.CalledAs())
+	}
+}
+
+func TestTraverse(t *testing.T) {
+	root, _, add := newFindTree()
+	root.TraverseChildren = true
+	var got []string
+	add.SetRun(func(c *Cmd, args []string) error {
+		got = args
+		return nil
+	})
+
+	root.SetOutputStream(&bytes.Buffer{})
+	root.SetErrorStream(&bytes.Buffer{})
+	root.SetArgs([]string{"--name", "n", "rm", "add", "x"})
+
+	cmd, err := root.ExecuteC()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if cmd != add || !reflect.DeepEqual(got, []string{"x"}) {
+		t.Errorf("ran %q with %q, want %q with [x]", cmd.Path(), got, add.Path())
+	}
+
+	// the local flag of the root is only known to the root, which parsed it
+	// while traversing
+	if name, err := root.Flags().GetString("name"); err != nil || name != "n" {
+		t.Errorf("root --name = %q, %v, want it parsed by Traverse", name, err)
+	}
+}
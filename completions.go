@@ -1,5 +1,23 @@
 package cli
 
+import (
+	"fmt"
+	"github.com/rsb/failure"
+	flag "github.com/rsb/pflag"
+	"io"
+	"strings"
+)
+
+const (
+	// ShellCompRequestCmd is the name of the hidden command that is used to request
+	// completion results from the program.  It is used by the shell completion scripts.
+	ShellCompRequestCmd = "__complete"
+
+	// ShellCompNoDescRequestCmd is the name of the hidden command that is used to request
+	// completion results without their description.  It is used by the shell completion scripts.
+	ShellCompNoDescRequestCmd = "__completeNoDesc"
+)
+
 // ShellCompDirective is a bit map representing the different behaviors the shell
 // can be instructed to have once completions have been provided.
 type ShellCompDirective int
@@ -18,7 +36,7 @@ const (
 
 	// ShellCompDirectiveFilterFileExt indicates that the provided completions
 	// should be used as file extension filters.
-	// For flags, using Command.MarkFlagFilename() and Command.MarkPersistentFlagFilename()
+	// For flags, using Cmd.MarkFlagFilename() and Cmd.MarkGlobalFlagFilename()
 	// is a shortcut to using this directive explicitly.  The BashCompFilenameExt
 	// annotation can also be used to obtain the same behavior for flags.
 	ShellCompDirectiveFilterFileExt
@@ -42,6 +60,35 @@ const (
 	ShellCompDirectiveDefault ShellCompDirective = 0
 )
 
+// string returns the names of the directives which are set, used for debugging.
+func (d ShellCompDirective) string() string {
+	var directives []string
+	if d&ShellCompDirectiveError != 0 {
+		directives = append(directives, "ShellCompDirectiveError")
+	}
+	if d&ShellCompDirectiveNoSpace != 0 {
+		directives = append(directives, "ShellCompDirectiveNoSpace")
+	}
+	if d&ShellCompDirectiveNoFileComp != 0 {
+		directives = append(directives, "ShellCompDirectiveNoFileComp")
+	}
+	if d&ShellCompDirectiveFilterFileExt != 0 {
+		directives = append(directives, "ShellCompDirectiveFilterFileExt")
+	}
+	if d&ShellCompDirectiveFilterDirs != 0 {
+		directives = append(directives, "ShellCompDirectiveFilterDirs")
+	}
+	if len(directives) == 0 {
+		directives = append(directives, "ShellCompDirectiveDefault")
+	}
+
+	if d >= shellCompDirectiveMaxValue {
+		return fmt.Sprintf("ERROR: unexpected ShellCompDirective value: %d", d)
+	}
+
+	return strings.Join(directives, ", ")
+}
+
 const (
 	// Constants for the completion command
 	compCmdName              = "completion"
@@ -50,16 +97,602 @@ const (
 	compCmdNoDescFlagDefault = false
 )
 
-// CompletionOptions are the options to control shell completion
+// CompletionOptions are the options to control shell completion. They are
+// read from the root command.
 type CompletionOptions struct {
-	// DisableDefaultCmd prevents Cobra from creating a default 'completion' command
+	// DisableDefaultCmd prevents the creation of a default 'completion' command.
+	// Without it the command is added to any root that has subcommands.
 	DisableDefaultCmd bool
-	// DisableNoDescFlag prevents Cobra from creating the '--no-descriptions' flag
+	// DisableNoDescFlag prevents the creation of the '--no-descriptions' flag
 	// for shells that support completion descriptions
 	DisableNoDescFlag bool
 	// DisableDescriptions turns off all completion descriptions for shells
 	// that support them
 	DisableDescriptions bool
-	// HiddenDefaultCmd makes the default 'completion' command hidden
-	HiddenDefaultCmd bool
+	// ShowDefaultCmd lists the default 'completion' command in help. It is
+	// hidden otherwise, so adding it does not change the help of a CLI.
+	ShowDefaultCmd bool
+}
+
+// initCompleteCmd adds a special hidden command that can be used to request custom completions.
+// It is only added when the args are requesting completions so it never shows up in the tree.
+func (c *Cmd) initCompleteCmd(args []string) {
+	for _, cmd := range c.commands {
+		if cmd.Name() == ShellCompRequestCmd {
+			return
+		}
+	}
+
+	completeCmd := &Cmd{
+		Use:                   fmt.Sprintf("%s [command-line]", ShellCompRequestCmd),
+		Aliases:               []string{ShellCompNoDescRequestCmd},
+		DisableFlagsInUseLine: true,
+		Hidden:                true,
+		DisableFlagParsing:    true,
+		Args:                  MinimumNArgs(1),
+		Short:                 "Request shell completion choices for the specified command-line",
+	}
+	completeCmd.SetRun(func(cmd *Cmd, args []string) error {
+		finalCmd, completions, directive, err := cmd.getCompletions(args)
+		if err != nil {
+			finalCmd.PrintErrln("[Error]:", err.Error())
+			// Keep going for multiple reasons:
+			// 1- There could be some valid completions even though there was an error
+			// 2- Even without completions, we need to print the directive
+		}
+
+		noDescriptions := cmd.CalledAs() == ShellCompNoDescRequestCmd
+		for _, comp := range completions {
+			if noDescriptions || c.CompletionOptions.DisableDescriptions {
+				// Remove any description that may be included following a tab character.
+				comp = strings.Split(comp, "\t")[0]
+			}
+
+			// Make sure we only write the first line to the output.
+			// This is needed if a description contains a linebreak.
+			// Otherwise the shell scripts will interpret the other lines as new flags
+			// and could therefore provide a wrong completion.
+			comp = strings.Split(comp, "\n")[0]
+
+			// Finally trim the completion.  This is especially important to get rid
+			// of a trailing tab when there are no description following it.
+			comp = strings.TrimSpace(comp)
+			finalCmd.Println(comp)
+		}
+
+		// As the last printout, print the completion directive for the completion script to parse.
+		// The directive integer must be that last character following a single colon (:).
+		// The completion script expects :<directive>
+		finalCmd.Printf(":%d\n", directive)
+		finalCmd.PrintErrf("Completion ended with directive: %s\n", directive.string())
+
+		return nil
+	})
+
+	c.Add(completeCmd)
+	subCmd, _, err := c.Find(args)
+	if err != nil || subCmd.Name() != ShellCompRequestCmd {
+		// Only create this special command if it is actually being called.
+		// This reduces possible side effects of creating such a command;
+		// for example, having this command would cause problems to a
+		// program that only consists of the root command, since this
+		// command would cause the root command to suddenly have a subcommand.
+		c.Remove(completeCmd)
+	}
+}
+
+// initDefaultCompletionCmd adds a default 'completion' command to c which
+// generates the completion scripts for bash, zsh, fish and powershell.
+// It does nothing when the command has no subcommands, when disabled through
+// CompletionOptions or when a 'completion' command already exists.
+func (c *Cmd) initDefaultCompletionCmd() {
+	if c.CompletionOptions.DisableDefaultCmd || !c.HasSubCommands() {
+		return
+	}
+
+	for _, cmd := range c.commands {
+		if cmd.Name() == compCmdName || cmd.HasAlias(compCmdName) {
+			return
+		}
+	}
+
+	haveNoDescFlag := !c.CompletionOptions.DisableNoDescFlag && !c.CompletionOptions.DisableDescriptions
+
+	completionCmd := &Cmd{
+		Use:   compCmdName,
+		Short: "Generate the autocompletion script for the specified shell",
+		Long: fmt.Sprintf(`Generate the autocompletion script for %[1]s for the specified shell.
+See each sub-command's help for details on how to use the generated script.
+`, c.Root().Name()),
+		Args:   NoArgs,
+		Hidden: !c.CompletionOptions.ShowDefaultCmd,
+	}
+	c.Add(completionCmd)
+
+	type shell struct {
+		name    string
+		long    string
+		hasDesc bool
+		gen     func(root *Cmd, w io.Writer, includeDesc bool) error
+	}
+
+	shells := []shell{
+		{
+			name: "bash",
+			long: `Generate the autocompletion script for bash. It depends on the
+'bash-completion' package.
+
+To load completions in your current shell session:
+
+	source <(%[1]s completion bash)
+
+To load completions for every new session, execute once:
+
+	%[1]s completion bash > /etc/bash_completion.d/%[1]s
+`,
+			gen: func(root *Cmd, w io.Writer, _ bool) error {
+				return root.GenBashCompletion(w)
+			},
+		},
+		{
+			name: "zsh",
+			long: `Generate the autocompletion script for zsh.
+
+If shell completion is not already enabled in your environment you will need
+to enable it. You can execute the following once:
+
+	echo "autoload -U compinit; compinit" >> ~/.zshrc
+
+To load completions for every new session, execute once:
+
+	%[1]s completion zsh > "${fpath[1]}/_%[1]s"
+`,
+			hasDesc: true,
+			gen: func(root *Cmd, w io.Writer, includeDesc bool) error {
+				return root.GenZshCompletion(w, includeDesc)
+			},
+		},
+		{
+			name: "fish",
+			long: `Generate the autocompletion script for fish.
+
+To load completions in your current shell session:
+
+	%[1]s completion fish | source
+
+To load completions for every new session, execute once:
+
+	%[1]s completion fish > ~/.config/fish/completions/%[1]s.fish
+`,
+			hasDesc: true,
+			gen: func(root *Cmd, w io.Writer, includeDesc bool) error {
+				return root.GenFishCompletion(w, includeDesc)
+			},
+		},
+		{
+			name: "powershell",
+			long: `Generate the autocompletion script for powershell.
+
+To load completions in your current shell session:
+
+	%[1]s completion powershell | Out-String | Invoke-Expression
+
+To load completions for every new session, add the output of the above
+command to your powershell profile.
+`,
+			hasDesc: true,
+			gen: func(root *Cmd, w io.Writer, includeDesc bool) error {
+				return root.GenPowerShellCompletion(w, includeDesc)
+			},
+		},
+	}
+
+	for _, sh := range shells {
+		sh := sh
+		noDesc := compCmdNoDescFlagDefault
+		shellCmd := &Cmd{
+			Use:                   sh.name,
+			Short:                 fmt.Sprintf("Generate the autocompletion script for %s", sh.name),
+			Long:                  fmt.Sprintf(sh.long, c.Root().Name()),
+			Args:                  NoArgs,
+			DisableFlagsInUseLine: true,
+			ValidArgsFunction:     noCompletions,
+		}
+		shellCmd.SetRun(func(cmd *Cmd, args []string) error {
+			return sh.gen(cmd.Root(), cmd.OutputStream(), !noDesc && !c.CompletionOptions.DisableDescriptions)
+		})
+
+		if sh.hasDesc && haveNoDescFlag {
+			shellCmd.Flags().BoolVar(&noDesc, compCmdNoDescFlagName, compCmdNoDescFlagDefault, compCmdNoDescFlagDesc)
+		}
+
+		completionCmd.Add(shellCmd)
+	}
+}
+
+// noCompletions is a ValidArgsFunction which completes nothing, not even files
+func noCompletions(cmd *Cmd, args []string, toComplete string) ([]string, ShellCompDirective) {
+	return nil, ShellCompDirectiveNoFileComp
+}
+
+// getCompletions resolves the command from the args, all but the last which
+// is the word being completed, and returns the completions for it.
+func (c *Cmd) getCompletions(args []string) (*Cmd, []string, ShellCompDirective, error) {
+	// The last argument, which is not completely typed by the user,
+	// should not be part of the list of arguments
+	toComplete := args[len(args)-1]
+	trimmedArgs := args[:len(args)-1]
+
+	var finalCmd *Cmd
+	var finalArgs []string
+	var err error
+	// Find the real command for which completion must be performed
+	// check if we need to traverse here to parse local flags on parent commands
+	if c.Root().TraverseChildren {
+		finalCmd, finalArgs, err = c.Root().Traverse(trimmedArgs)
+	} else {
+		finalCmd, finalArgs, err = c.Root().Find(trimmedArgs)
+	}
+	if err != nil {
+		// Unable to find the real command. E.g., <program> someInvalidCmd <TAB>
+		return c, []string{}, ShellCompDirectiveDefault, failure.NotFound("unable to find a command for arguments: %v", trimmedArgs)
+	}
+	finalCmd.ctx = c.ctx
+
+	// These flags are normally added when `execute()` is called on `finalCmd`,
+	// however, when doing completion, we don't call `finalCmd.execute()`.
+	// Let's add the --help and --version flag ourselves.
+	finalCmd.InitDefaultHelpFlag()
+	finalCmd.InitDefaultVersionFlag()
+
+	// Check if we are doing flag value completion before parsing the flags.
+	// This is important because if we are completing a flag value, we need to also
+	// remove the flag name argument from the list of finalArgs or else the parsing
+	// could fail due to an invalid value (incomplete) for the flag.
+	compFlag, finalArgs, toComplete, flagErr := checkIfFlagCompletion(finalCmd, finalArgs, toComplete)
+
+	// Check if interspersed is false or -- was set on a previous arg.
+	// This works by counting the arguments. Normally -- is not counted as arg but
+	// if -- was already set or interspersed is false and there is already one arg then
+	// the extra added -- is counted as arg.
+	flagCompletion := true
+	_ = finalCmd.ParseFlags(append(finalArgs, "--"))
+	newArgCount := finalCmd.Flags().NArg()
+
+	// Parse the flags early, so we can check if required flags are set
+	if err = finalCmd.ParseFlags(finalArgs); err != nil {
+		return finalCmd, []string{}, ShellCompDirectiveDefault, failure.Wrap(err, "error while parsing flags from args %v", finalArgs)
+	}
+
+	realArgCount := finalCmd.Flags().NArg()
+	if newArgCount > realArgCount {
+		// don't do flag completion (see above)
+		flagCompletion = false
+	}
+
+	// Error while attempting to parse flags
+	if flagErr != nil {
+		// If error type is flagCompError and we don't want flagCompletion we should ignore the error
+		if _, ok := flagErr.(*flagCompError); !(ok && !flagCompletion) {
+			return finalCmd, []string{}, ShellCompDirectiveDefault, flagErr
+		}
+	}
+
+	// We only remove the flags from the arguments if DisableFlagParsing is not set.
+	// This is important for commands which have requested to do their own flag completion.
+	if !finalCmd.DisableFlagParsing {
+		finalArgs = finalCmd.Flags().Args()
+	}
+
+	if compFlag != nil && flagCompletion {
+		completions, directive := finalCmd.flagValueCompletions(compFlag, toComplete)
+		return finalCmd, completions, directive, nil
+	}
+
+	var completions []string
+	directive := ShellCompDirectiveDefault
+
+	// Complete flag names when the user started typing a flag
+	if flagCompletion && len(toComplete) > 0 && toComplete[0] == '-' && !finalCmd.DisableFlagParsing {
+		return finalCmd, finalCmd.flagNameCompletions(toComplete), ShellCompDirectiveNoFileComp, nil
+	}
+
+	// Subcommands do not accept the local specific flags of their parent, so
+	// once one has been typed only the args of this command are completed.
+	foundLocalSpecificFlag := false
+	finalCmd.LocalSpecificFlags().VisitAll(func(f *flag.Flag) {
+		if f.Changed {
+			foundLocalSpecificFlag = true
+		}
+	})
+
+	if len(finalArgs) == 0 && !foundLocalSpecificFlag {
+		// Complete subcommand names
+		for _, subCmd := range finalCmd.Commands() {
+			if subCmd.IsAvailableCommand() && strings.HasPrefix(subCmd.Name(), toComplete) {
+				completions = append(completions, fmt.Sprintf("%s\t%s", subCmd.Name(), subCmd.Short))
+				directive = ShellCompDirectiveNoFileComp
+			}
+		}
+	}
+
+	argCompletions, argDirective, ok := finalCmd.argCompletions(finalArgs, toComplete)
+	if ok {
+		completions = append(completions, argCompletions...)
+		directive = argDirective
+	}
+
+	// Complete required flags even without the '-' prefix
+	if flagCompletion && !finalCmd.DisableFlagParsing {
+		completions = append(completions, finalCmd.requiredFlagCompletions(toComplete)...)
+	}
+
+	return finalCmd, completions, directive, nil
+}
+
+// argCompletions returns the candidates for the next positional arg from,
+// in order of preference, ValidArgs, ValidArgsFunction, the declared args and
+// the choices of the Use line, whatever validates the args. The bool is false
+// when none of them apply.
+func (c *Cmd) argCompletions(args []string, toComplete string) ([]string, ShellCompDirective, bool) {
+	var completions []string
+	switch {
+	case len(c.ValidArgs) > 0:
+		if len(args) > 0 {
+			return nil, ShellCompDirectiveNoFileComp, true
+		}
+		for _, validArg := range c.ValidArgs {
+			if strings.HasPrefix(validArg, toComplete) {
+				completions = append(completions, validArg)
+			}
+		}
+		return completions, ShellCompDirectiveNoFileComp, true
+	case c.ValidArgsFunction != nil:
+		completions, directive := c.ValidArgsFunction(c, args, toComplete)
+		return completions, directive, true
+	case c.HasDeclaredArgs():
+		declared := c.DeclaredArgs()
+		i := len(args)
+		if i >= len(declared) {
+			i = len(declared) - 1
+			if !declared[i].Variadic {
+				return nil, ShellCompDirectiveNoFileComp, true
+			}
+		}
+		if declared[i].Type != ArgEnum {
+			return nil, ShellCompDirectiveDefault, false
+		}
+		for _, v := range declared[i].Enum {
+			if strings.HasPrefix(v, toComplete) {
+				completions = append(completions, v)
+			}
+		}
+		return completions, ShellCompDirectiveNoFileComp, true
+	default:
+		g, err := ParseUse(c.Use)
+		if err != nil {
+			return nil, ShellCompDirectiveDefault, false
+		}
+		completions = g.Completions(args, toComplete)
+		if len(completions) == 0 {
+			return nil, ShellCompDirectiveDefault, false
+		}
+		return completions, ShellCompDirectiveNoFileComp, true
+	}
+}
+
+// flagNameCompletions returns the flags of the command which start with
+// toComplete, required flags first, then local flags and the inherited ones.
+// Flags already set are skipped unless they accept many values.
+func (c *Cmd) flagNameCompletions(toComplete string) []string {
+	var completions []string
+	excluded := c.flagGroupExclusions()
+	doCompleteFlags := func(f *flag.Flag) {
+		if excluded[f.Name] {
+			return
+		}
+
+		if !f.Changed || strings.Contains(f.Value.Type(), "Slice") || strings.Contains(f.Value.Type(), "Array") {
+			completions = append(completions, getFlagNameCompletions(f, toComplete)...)
+		}
+	}
+	completions = append(completions, c.requiredFlagCompletions(toComplete)...)
+	groupRequired := c.flagGroupRequirements()
+	c.LocalFlags().VisitAll(func(f *flag.Flag) {
+		if !isFlagRequired(f) && !groupRequired[f.Name] {
+			doCompleteFlags(f)
+		}
+	})
+	c.InheritedFlags().VisitAll(func(f *flag.Flag) {
+		if !isFlagRequired(f) && !groupRequired[f.Name] {
+			doCompleteFlags(f)
+		}
+	})
+
+	return completions
+}
+
+// requiredFlagCompletions returns the required flags, including the ones
+// required by flag groups, which have not been set and start with toComplete.
+func (c *Cmd) requiredFlagCompletions(toComplete string) []string {
+	var completions []string
+	excluded := c.flagGroupExclusions()
+	groupRequired := c.flagGroupRequirements()
+	c.Flags().VisitAll(func(f *flag.Flag) {
+		if excluded[f.Name] {
+			return
+		}
+
+		if (isFlagRequired(f) || groupRequired[f.Name]) && !f.Changed {
+			completions = append(completions, getFlagNameCompletions(f, toComplete)...)
+		}
+	})
+
+	return completions
+}
+
+// flagValueCompletions returns the completions for the value of f. Flags
+// marked with MarkFlagFilename complete the files with the given extensions
+// and flags marked with MarkFlagDirname complete directory names. Otherwise
+// values implementing ValueCompleter, like Enum, complete themselves.
+func (c *Cmd) flagValueCompletions(f *flag.Flag, toComplete string) ([]string, ShellCompDirective) {
+	if exts, ok := f.Annotations[BashCompFilenameExt]; ok {
+		if len(exts) == 0 {
+			// Any file is accepted
+			return []string{}, ShellCompDirectiveDefault
+		}
+		return exts, ShellCompDirectiveFilterFileExt
+	}
+
+	if dirs, ok := f.Annotations[BashCompSubdirsInDir]; ok {
+		if len(dirs) == 1 {
+			// Directories within the given directory
+			return dirs, ShellCompDirectiveFilterDirs
+		}
+		return []string{}, ShellCompDirectiveFilterDirs
+	}
+
+	if v, ok := f.Value.(ValueCompleter); ok {
+		return v.CompleteValue(toComplete)
+	}
+
+	return []string{}, ShellCompDirectiveDefault
+}
+
+func getFlagNameCompletions(f *flag.Flag, toComplete string) []string {
+	if nonCompletableFlag(f) {
+		return []string{}
+	}
+
+	var completions []string
+	flagName := "--" + f.Name
+	if strings.HasPrefix(flagName, toComplete) {
+		// Flag without the =
+		completions = append(completions, fmt.Sprintf("%s\t%s", flagName, f.Usage))
+	}
+
+	flagName = "-" + f.Short
+	if len(f.Short) > 0 && strings.HasPrefix(flagName, toComplete) {
+		completions = append(completions, fmt.Sprintf("%s\t%s", flagName, f.Usage))
+	}
+
+	return completions
+}
+
+func nonCompletableFlag(f *flag.Flag) bool {
+	return f.Hidden || len(f.Deprecated) > 0
+}
+
+type flagCompError struct {
+	subCommand string
+	flagName   string
+}
+
+func (e *flagCompError) Error() string {
+	return "Subcommand '" + e.subCommand + "' does not support flag '" + e.flagName + "'"
+}
+
+func checkIfFlagCompletion(finalCmd *Cmd, args []string, lastArg string) (*flag.Flag, []string, string, error) {
+	if finalCmd.DisableFlagParsing {
+		// We only do flag completion if we are allowed to parse flags
+		// This is important for commands which have requested to do their own flag completion.
+		return nil, args, lastArg, nil
+	}
+
+	var flagName string
+	trimmedArgs := args
+	flagWithEqual := false
+	orgLastArg := lastArg
+
+	// When doing completion of a flag name, as soon as an argument starts with
+	// a '-' we know it is a flag.  We cannot use isFlagArg() here as that function
+	// requires the flag name to be complete
+	if len(lastArg) > 0 && lastArg[0] == '-' {
+		if index := strings.Index(lastArg, "="); index >= 0 {
+			// Flag with an =
+			if strings.HasPrefix(lastArg[:index], "--") {
+				// Flag has full name
+				flagName = lastArg[2:index]
+			} else {
+				// Flag is shorthand
+				// We have to get the last shorthand flag name
+				// e.g. `-asd` => d to provide the correct completion
+				flagName = lastArg[index-1 : index]
+			}
+			lastArg = lastArg[index+1:]
+			flagWithEqual = true
+		} else {
+			// Normal flag completion
+			return nil, args, lastArg, nil
+		}
+	}
+
+	if len(flagName) == 0 {
+		if len(args) > 0 {
+			prevArg := args[len(args)-1]
+			if isFlagArg(prevArg) {
+				// Only consider the case where the flag does not contain an =.
+				// If the flag contains an = it means it has already been fully processed,
+				// so we don't need to deal with it here.
+				if index := strings.Index(prevArg, "="); index < 0 {
+					if strings.HasPrefix(prevArg, "--") {
+						// Flag has full name
+						flagName = prevArg[2:]
+					} else {
+						// Flag is shorthand
+						// We have to get the last shorthand flag name
+						// e.g. `-asd` => d to provide the correct completion
+						flagName = prevArg[len(prevArg)-1:]
+					}
+					// Remove the uncompleted flag or else there could be an error created
+					// for an invalid value for that flag
+					trimmedArgs = args[:len(args)-1]
+				}
+			}
+		}
+	}
+
+	if len(flagName) == 0 {
+		// Not doing flag completion
+		return nil, trimmedArgs, lastArg, nil
+	}
+
+	f := findFlag(finalCmd, flagName)
+	if f == nil {
+		// Flag not supported by this command, the interspersed option might be set so return the original args
+		return nil, args, orgLastArg, &flagCompError{subCommand: finalCmd.Name(), flagName: flagName}
+	}
+
+	if !flagWithEqual {
+		if len(f.NoOptDefVal) != 0 {
+			// We had assumed dealing with a two-word flag but the flag is a boolean flag.
+			// In that case, there is no value following it, so we are not really doing flag completion.
+			// Reset everything to do noun completion.
+			trimmedArgs = args
+			f = nil
+		}
+	}
+
+	return f, trimmedArgs, lastArg, nil
+}
+
+func findFlag(c *Cmd, name string) *flag.Flag {
+	c.mergeGlobalFlags()
+	flags := c.Flags()
+	if len(name) == 1 {
+		// First convert the short flag into a long flag
+		// as the cmd.Flag() search only accepts long flags
+		if short := flags.ShortLookup(name); short != nil {
+			name = short.Name
+		} else {
+			set := c.Root().GlobalFlags()
+			if short = set.ShortLookup(name); short != nil {
+				name = short.Name
+			} else {
+				return nil
+			}
+		}
+	}
+
+	return flags.Lookup(name)
 }
@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestDefaultCompletionCmd_Hidden(t *testing.T) {
+	tests := []struct {
+		name string
+		show bool
+	}{
+		{name: "hidden by default"},
+		{name: "shown", show: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := &Cmd{Use: "app"}
+			sub := &Cmd{Use: "sub", Short: "a subcommand"}
+			sub.SetRun(func(c *Cmd, args []string) error { return nil })
+			root.Add(sub)
+			root.CompletionOptions.ShowDefaultCmd = tt.show
+
+			out := &bytes.Buffer{}
+			root.SetOutputStream(out)
+			root.SetErrorStream(&bytes.Buffer{})
+			root.SetArgs([]string{"--help"})
+			if err := root.Execute(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := strings.Contains(out.String(), compCmdName); got != tt.show {
+				t.Errorf("help lists %s = %v, want %v in %q", compCmdName, got, tt.show, out.String())
+			}
+
+			out.Reset()
+			root.SetArgs([]string{compCmdName, "bash"})
+			if err := root.Execute(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !strings.Contains(out.String(), "__start_app") {
+				t.Errorf("expected the bash script, got %q", out.String())
+			}
+		})
+	}
+}
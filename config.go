@@ -0,0 +1,381 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"github.com/rsb/failure"
+	flag "github.com/rsb/pflag"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Config configures the config files which provide flag values when they are
+// not given on the command line or by the environment. It is only honored on
+// the root command.
+//
+// Files are looked up in layers, each one overriding the one before:
+//
+//	<user config dir>/<name>/config.json, config.ini and config.env
+//	./.<name>.json, ./.<name>.ini and ./.env
+//	every file in Paths
+//	the file given with --<flag>
+//
+// Keys are the flag names, prefixed by the path of the command they apply to
+// below the root: "log-level" is the flag of the root command, and of every
+// command when it is global, while "serve.port" is only the flag of serve.
+// JSON objects and INI sections nest the keys. Dotenv files are matched
+// against the environment variables of the flags instead.
+//
+// Name:	base name of the files, defaults to the name of the root command
+// Paths:	extra files loaded after the ones in the default locations
+// Flag:	global flag giving an explicit file, defaults to "config"
+type Config struct {
+	Name  string
+	Paths []string
+	Flag  string
+
+	// files loaded by the last execution
+	files []string
+}
+
+type configFormat int
+
+const (
+	configJSON configFormat = iota
+	configINI
+	configDotenv
+)
+
+// configLayer is a single config file flattened to its keys
+type configLayer struct {
+	path   string
+	format configFormat
+	values map[string][]string
+}
+
+// SetConfig enables config files for the command tree and defines the
+// global flag giving an explicit file. Both are stored on the root command.
+func (c *Cmd) SetConfig(cfg Config) {
+	root := c.Root()
+	if cfg.Flag == "" {
+		cfg.Flag = "config"
+	}
+
+	if cfg.Name == "" {
+		cfg.Name = root.Name()
+	}
+
+	root.config = &cfg
+	if root.GlobalFlags().Lookup(cfg.Flag) == nil {
+		root.GlobalFlags().String(cfg.Flag, "", "config file, read after the files in the default locations")
+	}
+}
+
+// ConfigFiles returns the config files loaded by the last execution, in the
+// order they were applied.
+func (c *Cmd) ConfigFiles() []string {
+	cfg := c.Root().config
+	if cfg == nil {
+		return nil
+	}
+
+	return cfg.files
+}
+
+// ConfigKeys returns the keys which can set the named flag in config files,
+// from the most to the least specific.
+func (c *Cmd) ConfigKeys(name string) []string {
+	var path []string
+	levels := []*Cmd{c}
+	for p := c; p.HasParent(); p = p.Parent() {
+		path = append([]string{p.Name()}, path...)
+		levels = append([]*Cmd{p.Parent()}, levels...)
+	}
+
+	var keys []string
+	for i := len(path); i >= 0; i-- {
+		levels[i].mergeGlobalFlags()
+		if levels[i].Flags().Lookup(name) == nil {
+			continue
+		}
+
+		keys = append(keys, strings.Join(append(path[:i:i], name), "."))
+	}
+
+	return keys
+}
+
+// applyConfig sets the flags which were not set on the command line or by
+// the environment from the config files.
+func (c *Cmd) applyConfig() error {
+	cfg := c.Root().config
+	if cfg == nil || c.DisableFlagParsing {
+		return nil
+	}
+
+	flags := c.Flags()
+	explicit, _ := flags.GetString(cfg.Flag)
+	layers, err := loadConfigLayers(cfg, explicit)
+	if err != nil {
+		return err
+	}
+
+	cfg.files = nil
+	for _, l := range layers {
+		cfg.files = append(cfg.files, l.path)
+	}
+
+	flags.VisitAll(func(f *flag.Flag) {
+		if err != nil || f.Changed || f.Name == "help" || f.Name == "version" || f.Name == cfg.Flag {
+			return
+		}
+
+		if _, negates := negatedFlag(f); negates {
+			return
+		}
+
+		source, values, ok := c.configValue(layers, f)
+		if !ok {
+			return
+		}
+
+		for _, value := range values {
+			if setErr := flags.Set(f.Name, value); setErr != nil {
+				err = &FlagSourceError{Path: c.Path(), Flag: f.Name, Source: source, Value: redactFlagValue(f, value), Err: redactFlagError(f, setErr)}
+				return
+			}
+		}
+		c.setFlagOrigin(f.Name, FlagSourceConfig, source)
+	})
+
+	return err
+}
+
+// configValue finds the value of f in the last layer which has it
+func (c *Cmd) configValue(layers []configLayer, f *flag.Flag) (string, []string, bool) {
+	keys := c.ConfigKeys(f.Name)
+	for i := len(layers) - 1; i >= 0; i-- {
+		l := layers[i]
+		candidates := keys
+		if l.format == configDotenv {
+			candidates = c.flagEnvVars(f)
+		}
+
+		for _, key := range candidates {
+			if values, ok := l.values[key]; ok {
+				return fmt.Sprintf("%s (%s)", l.path, key), values, true
+			}
+		}
+	}
+
+	return "", nil, false
+}
+
+// loadConfigLayers reads the config files from the default locations, the
+// extra paths and the explicit file. Missing files are skipped except for the
+// explicit one.
+func loadConfigLayers(cfg *Config, explicit string) ([]configLayer, error) {
+	var candidates []string
+	if dir, err := os.UserConfigDir(); err == nil {
+		for _, name := range []string{"config.json", "config.ini", "config.env"} {
+			candidates = append(candidates, filepath.Join(dir, cfg.Name, name))
+		}
+	}
+
+	candidates = append(candidates, "."+cfg.Name+".json", "."+cfg.Name+".ini", ".env")
+	candidates = append(candidates, cfg.Paths...)
+
+	var layers []configLayer
+	for _, path := range candidates {
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+
+		l, err := readConfigFile(path)
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, l)
+	}
+
+	if explicit != "" {
+		l, err := readConfigFile(explicit)
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, l)
+	}
+
+	return layers, nil
+}
+
+func readConfigFile(path string) (configLayer, error) {
+	l := configLayer{path: path, values: map[string][]string{}}
+
+	base := filepath.Base(path)
+	switch ext := strings.ToLower(filepath.Ext(base)); {
+	case ext == ".json":
+		l.format = configJSON
+	case ext == ".ini" || ext == ".conf" || ext == ".cfg":
+		l.format = configINI
+	case ext == ".env" || strings.HasPrefix(base, ".env"):
+		l.format = configDotenv
+	default:
+		return l, failure.InvalidParam("config file (%s), unknown format, expected .json, .ini or .env", path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return l, failure.Wrap(err, "config file (%s)", path)
+	}
+
+	switch l.format {
+	case configJSON:
+		err = parseJSONConfig(data, l.values)
+	case configINI:
+		err = parseINIConfig(data, l.values)
+	default:
+		err = parseDotenvConfig(data, l.values)
+	}
+
+	if err != nil {
+		return l, failure.Wrap(err, "config file (%s)", path)
+	}
+
+	return l, nil
+}
+
+func parseJSONConfig(data []byte, values map[string][]string) error {
+	dec := json.NewDecoder(strings.NewReader(string(data)))
+	dec.UseNumber()
+
+	var doc map[string]interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return failure.InvalidParam("invalid json: %v", err)
+	}
+
+	flattenJSONConfig("", doc, values)
+	return nil
+}
+
+// flattenJSONConfig records every scalar and array under its dotted key.
+// Objects of scalars are also recorded as k=v pairs so they can set map
+// flags.
+func flattenJSONConfig(prefix string, obj map[string]interface{}, values map[string][]string) {
+	var pairs []string
+	for k, v := range obj {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+
+		switch tv := v.(type) {
+		case map[string]interface{}:
+			flattenJSONConfig(key, tv, values)
+		case []interface{}:
+			for _, item := range tv {
+				if s, ok := jsonScalar(item); ok {
+					values[key] = append(values[key], s)
+				}
+			}
+		default:
+			if s, ok := jsonScalar(tv); ok {
+				values[key] = []string{s}
+				pairs = append(pairs, k+"="+s)
+			}
+		}
+	}
+
+	if prefix != "" && len(pairs) == len(obj) {
+		sort.Strings(pairs)
+		values[prefix] = []string{strings.Join(pairs, ",")}
+	}
+}
+
+func jsonScalar(v interface{}) (string, bool) {
+	switch tv := v.(type) {
+	case string:
+		return tv, true
+	case json.Number:
+		return tv.String(), true
+	case bool:
+		return strconv.FormatBool(tv), true
+	default:
+		return "", false
+	}
+}
+
+// parseINIConfig reads key = value pairs, keys below a [section] are
+// prefixed with the section. Lines starting with ; or # are comments.
+func parseINIConfig(data []byte, values map[string][]string) error {
+	section := ""
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, ";") || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				return failure.InvalidParam("line %d: unterminated section %q", n, line)
+			}
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+
+		key, value, ok := cutConfigPair(line, "=:")
+		if !ok {
+			return failure.InvalidParam("line %d: expected key = value, got %q", n, line)
+		}
+
+		if section != "" {
+			key = section + "." + key
+		}
+		values[key] = []string{value}
+	}
+
+	return scanner.Err()
+}
+
+// parseDotenvConfig reads KEY=value pairs, optionally preceded by export.
+// Lines starting with # are comments.
+func parseDotenvConfig(data []byte, values map[string][]string) error {
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		line = strings.TrimPrefix(line, "export ")
+		key, value, ok := cutConfigPair(line, "=")
+		if !ok {
+			return failure.InvalidParam("line %d: expected KEY=value, got %q", n, line)
+		}
+		values[key] = []string{value}
+	}
+
+	return scanner.Err()
+}
+
+// cutConfigPair splits line at the first of seps, trimming spaces and the
+// quotes around the value.
+func cutConfigPair(line, seps string) (string, string, bool) {
+	i := strings.IndexAny(line, seps)
+	if i <= 0 {
+		return "", "", false
+	}
+
+	key := strings.TrimSpace(line[:i])
+	value := strings.TrimSpace(line[i+1:])
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		value = value[1 : len(value)-1]
+	}
+
+	return key, value, true
+}
@@ -0,0 +1,170 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseJSONConfig(t *testing.T) {
+	data := `{
+		"log-level": "debug",
+		"port": 8080,
+		"verbose": true,
+		"tags": ["a", "b", 3],
+		"serve": {"port": 9090, "tls": {"cert": "c.pem"}},
+		"labels": {"env": "prod", "team": "core"},
+		"skip": null
+	}`
+
+	values := map[string][]string{}
+	if err := parseJSONConfig([]byte(data), values); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string][]string{
+		"log-level":      {"debug"},
+		"port":           {"8080"},
+		"verbose":        {"true"},
+		"tags":           {"a", "b", "3"},
+		"serve.port":     {"9090"},
+		"serve.tls.cert": {"c.pem"},
+		"serve.tls":      {"cert=c.pem"},
+		"labels.env":     {"prod"},
+		"labels.team":    {"core"},
+		"labels":         {"env=prod,team=core"},
+	}
+	if !reflect.DeepEqual(values, want) {
+		t.Errorf("values = %v, want %v", values, want)
+	}
+}
+
+func TestParseJSONConfig_Invalid(t *testing.T) {
+	for _, data := range []string{`{"a":`, `["a"]`, `not json`} {
+		if err := parseJSONConfig([]byte(data), map[string][]string{}); err == nil {
+			t.Errorf("parseJSONConfig(%q) expected an error", data)
+		}
+	}
+}
+
+func TestParseINIConfig(t *testing.T) {
+	data := `
+; comment
+# comment
+log-level = debug
+name: "quoted value"
+
+[serve]
+port = 9090
+host = 'local host'
+url = http://x?a=b
+`
+
+	values := map[string][]string{}
+	if err := parseINIConfig([]byte(data), values); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string][]string{
+		"log-level":  {"debug"},
+		"name":       {"quoted value"},
+		"serve.port": {"9090"},
+		"serve.host": {"local host"},
+		"serve.url":  {"http://x?a=b"},
+	}
+	if !reflect.DeepEqual(values, want) {
+		t.Errorf("values = %v, want %v", values, want)
+	}
+}
+
+func TestParseINIConfig_Invalid(t *testing.T) {
+	tests := []struct {
+		data string
+		want string
+	}{
+		{data: "[serve\nport = 1", want: "line 1: unterminated section"},
+		{data: "a = 1\njust a line", want: "line 2: expected key = value"},
+		{data: "= value", want: "line 1: expected key = value"},
+	}
+
+	for _, tt := range tests {
+		err := parseINIConfig([]byte(tt.data), map[string][]string{})
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("parseINIConfig(%q) error = %v, want it to contain %q", tt.data, err, tt.want)
+		}
+	}
+}
+
+func TestParseDotenvConfig(t *testing.T) {
+	data := `
+# comment
+APP_PORT=8080
+export APP_HOST = "local host"
+APP_EMPTY=
+APP_URL=http://x?a=b
+`
+
+	values := map[string][]string{}
+	if err := parseDotenvConfig([]byte(data), values); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string][]string{
+		"APP_PORT":  {"8080"},
+		"APP_HOST":  {"local host"},
+		"APP_EMPTY": {""},
+		"APP_URL":   {"http://x?a=b"},
+	}
+	if !reflect.DeepEqual(values, want) {
+		t.Errorf("values = %v, want %v", values, want)
+	}
+
+	if err := parseDotenvConfig([]byte("APP_PORT 8080"), map[string][]string{}); err == nil {
+		t.Error("expected an error for a line without =")
+	}
+}
+
+func TestReadConfigFile_Formats(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name   string
+		data   string
+		format configFormat
+		err    string
+	}{
+		{name: "app.json", data: `{"a":"1"}`, format: configJSON},
+		{name: "app.ini", data: "a = 1", format: configINI},
+		{name: "app.conf", data: "a = 1", format: configINI},
+		{name: "app.cfg", data: "a = 1", format: configINI},
+		{name: "app.env", data: "A=1", format: configDotenv},
+		{name: ".env.local", data: "A=1", format: configDotenv},
+		{name: "app.yaml", data: "a: 1", err: "unknown format"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.name)
+			if err := os.WriteFile(path, []byte(tt.data), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			l, err := readConfigFile(path)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("error = %v, want it to contain %q", err, tt.err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if l.format != tt.format || len(l.values) != 1 {
+				t.Errorf("format = %v, values = %v", l.format, l.values)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"fmt"
+	"github.com/rsb/failure"
+	"strings"
+)
+
+// Deprecation describes what replaces a deprecated command and when it goes
+// away. It is rendered in the deprecation notice and in help.
+// Replacement:	path of the replacing command below the root, like "config set"
+// Forward:		run the replacement with the same args instead of the command
+// RemovedIn:	version in which the command will be removed
+type Deprecation struct {
+	Replacement string
+	Forward     bool
+	RemovedIn   string
+}
+
+// IsZero determines if no deprecation details were given
+func (d Deprecation) IsZero() bool {
+	return d == Deprecation{}
+}
+
+// IsDeprecated determines if the command is deprecated, either with a
+// Deprecated message or Deprecation details.
+func (c *Cmd) IsDeprecated() bool {
+	return c.Deprecated != "" || !c.Deprecation.IsZero()
+}
+
+// DeprecationNotice returns the notice printed to the error stream when a
+// deprecated command is invoked.
+func (c *Cmd) DeprecationNotice() string {
+	if !c.IsDeprecated() {
+		return ""
+	}
+
+	notice := fmt.Sprintf("Command %q is deprecated", c.Name())
+	if c.Deprecated != "" {
+		notice += ", " + c.Deprecated
+	}
+
+	if r := c.Deprecation.Replacement; r != "" {
+		notice += fmt.Sprintf("; use %q instead", c.Root().Name()+" "+r)
+	}
+
+	if v := c.Deprecation.RemovedIn; v != "" {
+		notice += fmt.Sprintf("; it will be removed in %s", v)
+	}
+
+	return notice
+}
+
+// forwardDeprecated returns the replacement of the command when it is
+// deprecated and forwards to it, otherwise the command itself.
+func (c *Cmd) forwardDeprecated() (*Cmd, error) {
+	d := c.Deprecation
+	if !d.Forward || d.Replacement == "" {
+		return c, nil
+	}
+
+	path := strings.Fields(d.Replacement)
+	target, rest, err := c.Root().Find(path)
+	if err != nil || len(rest) > 0 || target == c.Root() {
+		return c, failure.NotFound("replacement (%s) of deprecated command (%s)", d.Replacement, c.Path())
+	}
+
+	if target == c {
+		return c, failure.InvalidState("deprecated command (%s) forwards to itself", c.Path())
+	}
+
+	c.PrintErrln(c.DeprecationNotice())
+	if target.ctx == nil {
+		target.ctx = c.ctx
+	}
+
+	return target, nil
+}
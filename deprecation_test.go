@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"bytes"
+	"github.com/rsb/failure"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestForwardDeprecated(t *testing.T) {
+	tests := []struct {
+		name        string
+		deprecation Deprecation
+		ran         string
+		err         func(error) bool
+	}{
+		{name: "not forwarded", deprecation: Deprecation{Replacement: "config set"}, ran: "app old"},
+		{name: "forwarded", deprecation: Deprecation{Replacement: "config set", Forward: true}, ran: "app config set"},
+		{name: "replacement not found", deprecation: Deprecation{Replacement: "config unset", Forward: true}, err: failure.IsNotFound},
+		{name: "replacement with extra words", deprecation: Deprecation{Replacement: "config set x", Forward: true}, err: failure.IsNotFound},
+		{name: "forwards to itself", deprecation: Deprecation{Replacement: "old", Forward: true}, err: failure.IsInvalidState},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ran string
+			var got []string
+			run := func(c *Cmd, args []string) error {
+				ran, got = c.Path(), args
+				return nil
+			}
+
+			root := &Cmd{Use: "app"}
+			root.GlobalFlags().Bool("debug", false, "debug")
+			config := &Cmd{Use: "config"}
+			set := &Cmd{Use: "set"}
+			set.SetRun(run)
+			config.Add(set)
+			old := &Cmd{Use: "old", Deprecation: tt.deprecation}
+			old.SetRun(run)
+			root.Add(config, old)
+
+			errs := &bytes.Buffer{}
+			root.SetOutputStream(&bytes.Buffer{})
+			root.SetErrorStream(errs)
+			root.SetArgs([]string{"old", "key", "--debug", "value"})
+
+			err := root.Execute()
+			if tt.err != nil {
+				if !tt.err(err) {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if ran != "" {
+					t.Errorf("expected nothing to run, %q ran", ran)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if ran != tt.ran || !reflect.DeepEqual(got, []string{"key", "value"}) {
+				t.Errorf("ran %q with %q, want %q with the args of old", ran, got, tt.ran)
+			}
+
+			if debug, _ := root.GlobalFlags().GetBool("debug"); !debug {
+				t.Error("expected the flags to be forwarded")
+			}
+
+			if !strings.Contains(errs.String(), `Command "old" is deprecated; use "app config set" instead`) {
+				t.Errorf("notice = %q", errs.String())
+			}
+		})
+	}
+}
+
+func TestDeprecationNotice(t *testing.T) {
+	root := &Cmd{Use: "app"}
+	old := &Cmd{
+		Use:         "old",
+		Deprecated:  "it is slow",
+		Deprecation: Deprecation{Replacement: "new", RemovedIn: "v2.0.0"},
+	}
+	root.Add(old)
+
+	want := `Command "old" is deprecated, it is slow; use "app new" instead; it will be removed in v2.0.0`
+	if got := old.DeprecationNotice(); got != want {
+		t.Errorf("DeprecationNotice() = %q, want %q", got, want)
+	}
+
+	if got := root.DeprecationNotice(); got != "" {
+		t.Errorf("DeprecationNotice() = %q for a command which is not deprecated", got)
+	}
+}
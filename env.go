@@ -0,0 +1,174 @@
+package cli
+
+import (
+	"fmt"
+	"github.com/rsb/failure"
+	flag "github.com/rsb/pflag"
+	"os"
+	"strings"
+)
+
+// FlagAnnotationEnv holds the environment variables bound to a flag, in the
+// order they are looked up.
+const FlagAnnotationEnv = "cli_annotation_flag_env"
+
+// Env configures how flags are read from environment variables. It is only
+// honored on the root command.
+// Automatic:	bind every flag to PREFIX_FLAG_NAME unless explicitly bound
+// Prefix:		prepended to the automatic variable names
+type Env struct {
+	Automatic bool
+	Prefix    string
+}
+
+// FlagSourceError is returned when a value which did not come from the
+// command line, like an environment variable, is rejected by its flag.
+// Source describes where the value came from.
+type FlagSourceError struct {
+	Path   string
+	Flag   string
+	Source string
+	Value  string
+	Err    error
+}
+
+func (e *FlagSourceError) Error() string {
+	return fmt.Sprintf("%s: invalid value %q for flag (%s) from %s: %v", e.Path, e.Value, e.Flag, e.Source, e.Err)
+}
+
+// Unwrap returns the error of the flag, allowing failure.IsInvalidParam to
+// identify it
+func (e *FlagSourceError) Unwrap() error {
+	return e.Err
+}
+
+// BindFlagEnv binds the named flag to one or more environment variables.
+// When the flag is not set on the command line the first variable that is
+// set provides its value.
+func (c *Cmd) BindFlagEnv(name string, vars ...string) error {
+	c.mergeGlobalFlags()
+	return BindFlagEnv(c.Flags(), name, vars...)
+}
+
+// BindFlagEnv binds the named flag in flags to one or more environment
+// variables.
+func BindFlagEnv(flags *flag.FlagSet, name string, vars ...string) error {
+	f := flags.Lookup(name)
+	if f == nil {
+		return failure.NotFound("flag (%s), does not exist", name)
+	}
+
+	if len(vars) == 0 {
+		return failure.InvalidParam("no environment variable given for flag (%s)", name)
+	}
+
+	values := f.Annotations[FlagAnnotationEnv]
+	for _, v := range vars {
+		if !stringInSlice(v, values) {
+			values = append(values, v)
+		}
+	}
+
+	return flags.SetAnnotation(name, FlagAnnotationEnv, values)
+}
+
+// SetAutomaticEnv binds every flag of the command tree to an environment
+// variable made of the prefix and the flag name, after GlobalNormalizeFlagFn,
+// in upper snake case: with the prefix APP the flag --log-level is bound to
+// APP_LOG_LEVEL. Flags with an explicit binding and the help and version
+// flags are left alone. It is stored on the root of the tree c belongs to
+// when it is called, so it applies to every command of that tree.
+func (c *Cmd) SetAutomaticEnv(prefix string) {
+	c.Root().env = Env{Automatic: true, Prefix: prefix}
+}
+
+// Env returns the environment settings of the command tree
+func (c *Cmd) Env() Env {
+	return c.Root().env
+}
+
+// FlagEnvVars returns the environment variables the named flag is read from
+func (c *Cmd) FlagEnvVars(name string) []string {
+	c.mergeGlobalFlags()
+	f := c.Flags().Lookup(name)
+	if f == nil {
+		return nil
+	}
+
+	return c.flagEnvVars(f)
+}
+
+func (c *Cmd) flagEnvVars(f *flag.Flag) []string {
+	if vars, ok := f.Annotations[FlagAnnotationEnv]; ok {
+		return vars
+	}
+
+	env := c.Env()
+	if !env.Automatic || f.Name == "help" || f.Name == "version" {
+		return nil
+	}
+
+	// the environment sets the negatable flag itself, never its negation
+	if _, ok := negatedFlag(f); ok {
+		return nil
+	}
+
+	name := f.Name
+	if c.IsGlobalNormalizationEnabled() {
+		name = string(c.GlobalNormalization()(c.Flags(), name))
+	}
+
+	return []string{envVarName(env.Prefix, name)}
+}
+
+// applyFlagEnv sets the flags which were not set on the command line from
+// their environment variables.
+func (c *Cmd) applyFlagEnv() error {
+	if c.DisableFlagParsing {
+		return nil
+	}
+
+	flags := c.Flags()
+	var err error
+	flags.VisitAll(func(f *flag.Flag) {
+		if err != nil || f.Changed {
+			return
+		}
+
+		for _, v := range c.flagEnvVars(f) {
+			value, ok := os.LookupEnv(v)
+			if !ok {
+				continue
+			}
+
+			if setErr := flags.Set(f.Name, value); setErr != nil {
+				err = &FlagSourceError{Path: c.Path(), Flag: f.Name, Source: "$" + v, Value: redactFlagValue(f, value), Err: redactFlagError(f, setErr)}
+				return
+			}
+			c.setFlagOrigin(f.Name, FlagSourceEnv, "$"+v)
+			return
+		}
+	})
+
+	return err
+}
+
+// envUsage describes the environment variables of f for help
+func (c *Cmd) envUsage(f *flag.Flag) string {
+	vars := c.flagEnvVars(f)
+	if len(vars) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("(env: %s)", strings.Join(vars, ", "))
+}
+
+// envVarName returns PREFIX_NAME, name is upper-cased and - and . become _
+func envVarName(prefix, name string) string {
+	name = strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(name))
+	if prefix == "" {
+		return name
+	}
+
+	return strings.TrimSuffix(prefix, "_") + "_" + name
+}
@@ -0,0 +1,32 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSetAutomaticEnv_OnSubcommand(t *testing.T) {
+	var level string
+	root := &Cmd{Use: "app"}
+	sub := &Cmd{Use: "serve"}
+	sub.Flags().StringVar(&level, "log-level", "info", "log level")
+	sub.SetRun(func(c *Cmd, args []string) error { return nil })
+	root.Add(sub)
+	sub.SetAutomaticEnv("APP")
+
+	if root.Env() != sub.Env() || !root.Env().Automatic {
+		t.Fatalf("Env() = %+v on the root and %+v on the subcommand", root.Env(), sub.Env())
+	}
+
+	t.Setenv("APP_LOG_LEVEL", "debug")
+	root.SetOutputStream(&bytes.Buffer{})
+	root.SetErrorStream(&bytes.Buffer{})
+	root.SetArgs([]string{"serve"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if level != "debug" {
+		t.Errorf("log-level = %q, want debug", level)
+	}
+}
@@ -0,0 +1,138 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+)
+
+// GenFishCompletion generates the fish completion script for the root
+// command and writes it to w. When includeDesc is false the completions are
+// shown without their descriptions.
+func (c *Cmd) GenFishCompletion(w io.Writer, includeDesc bool) error {
+	name := c.Root().Name()
+	compCmd := ShellCompRequestCmd
+	if !includeDesc {
+		compCmd = ShellCompNoDescRequestCmd
+	}
+
+	_, err := fmt.Fprintf(w, fishCompletionTemplate, name, compCmd,
+		ShellCompDirectiveError, ShellCompDirectiveNoSpace, ShellCompDirectiveNoFileComp,
+		ShellCompDirectiveFilterFileExt, ShellCompDirectiveFilterDirs)
+
+	return err
+}
+
+const fishCompletionTemplate = `# fish completion for %-36[1]s -*- shell-script -*-
+
+function __%[1]s_debug
+    set -l file "$BASH_COMP_DEBUG_FILE"
+    if test -n "$file"
+        echo "$argv" >> $file
+    end
+end
+
+function __%[1]s_has_directive
+    test (math (math --scale 0 $argv[1] / $argv[2]) %% 2) -eq 1
+end
+
+function __%[1]s_complete
+    set -l shellCompDirectiveError %[3]d
+    set -l shellCompDirectiveNoSpace %[4]d
+    set -l shellCompDirectiveNoFileComp %[5]d
+    set -l shellCompDirectiveFilterFileExt %[6]d
+    set -l shellCompDirectiveFilterDirs %[7]d
+
+    set -l args (commandline -opc)
+    set -l lastArg (commandline -ct)
+    set -l requestComp "$args[1] %[2]s $args[2..-1] "(string escape -- $lastArg)
+
+    __%[1]s_debug "Calling $requestComp"
+    set -l results (eval $requestComp 2> /dev/null)
+
+    # Ignore any empty line printed after the directive
+    for line in $results[-1..1]
+        if test (string trim -- $line) = ""
+            set results $results[1..-2]
+        else
+            break
+        end
+    end
+
+    set -l directive (string sub --start 2 -- $results[-1])
+    set -l comps $results[1..-2]
+    if test -z "$directive"
+        set directive 0
+    end
+    __%[1]s_debug "The completion directive is: $directive"
+
+    # When completing a flag with an = the completions must be prefixed
+    # with the flag
+    set -l flagPrefix (string match -r -- '^-.*=' "$lastArg")
+    set -l current (string replace -r -- '^-.*=' '' "$lastArg")
+
+    if __%[1]s_has_directive $directive $shellCompDirectiveError
+        return
+    end
+
+    if __%[1]s_has_directive $directive $shellCompDirectiveFilterFileExt
+        # File extension filtering, directories are kept to allow descending
+        for f in $current*
+            if test -d $f
+                echo $flagPrefix$f/
+                continue
+            end
+            for ext in $comps
+                if string match -q -- "*.$ext" $f
+                    echo $flagPrefix$f
+                end
+            end
+        end
+        return
+    end
+
+    if __%[1]s_has_directive $directive $shellCompDirectiveFilterDirs
+        # File completion for directories only
+        set -l subdir $comps[1]
+        set -l base $current
+        if test -n "$subdir"
+            set base $subdir/$current
+        end
+        for f in $base*
+            if test -d $f
+                if test -n "$subdir"
+                    set f (string replace -- "$subdir/" '' $f)
+                end
+                echo $flagPrefix$f/
+            end
+        end
+        return
+    end
+
+    set -l matches
+    for comp in $comps
+        if string match -q -- "$current*" (string split -m 1 \t -- $comp)[1]
+            set matches $matches $flagPrefix$comp
+        end
+    end
+
+    if test (count $matches) -eq 0
+        if not __%[1]s_has_directive $directive $shellCompDirectiveNoFileComp
+            __fish_complete_path $current
+        end
+        return
+    end
+
+    if __%[1]s_has_directive $directive $shellCompDirectiveNoSpace; and test (count $matches) -eq 1
+        # fish adds a space after a single completion, a second completion
+        # differing only by a trailing dot prevents it
+        set -l value (string split -m 1 \t -- $matches[1])[1]
+        set matches $value $value.
+    end
+
+    printf "%%s\n" $matches
+end
+
+# Remove any previous completion for the program before registering ours
+complete -c %[1]s -e
+complete -c %[1]s -f -a '(__%[1]s_complete)'
+`
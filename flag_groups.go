@@ -0,0 +1,266 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/rsb/failure"
+	flag "github.com/rsb/pflag"
+	"sort"
+	"strings"
+)
+
+// Annotations for flag groups. The value of each annotation is the list of
+// groups the flag belongs to, every group is the names of its flags joined
+// by a space.
+const (
+	FlagGroupRequiredTogether  = "cli_annotation_flag_group_required_together"
+	FlagGroupMutuallyExclusive = "cli_annotation_flag_group_mutually_exclusive"
+	FlagGroupOneRequired       = "cli_annotation_flag_group_one_required"
+)
+
+// flagGroupKinds lists the group annotations in the order they are
+// validated and shown in help.
+var flagGroupKinds = []string{
+	FlagGroupRequiredTogether,
+	FlagGroupMutuallyExclusive,
+	FlagGroupOneRequired,
+}
+
+var flagGroupDescriptions = map[string]string{
+	FlagGroupRequiredTogether:  "must be used together",
+	FlagGroupMutuallyExclusive: "mutually exclusive",
+	FlagGroupOneRequired:       "at least one is required",
+}
+
+// FlagGroupError is returned when the flags of a group do not satisfy its
+// constraint. Kind is one of the FlagGroup annotations.
+type FlagGroupError struct {
+	Path   string
+	Kind   string
+	Group  []string
+	Reason string
+}
+
+func (e *FlagGroupError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Reason)
+}
+
+// Unwrap allows failure.IsInvalidParam to identify the error
+func (e *FlagGroupError) Unwrap() error {
+	return failure.InvalidParam("flag group %v for (%s)", e.Group, e.Path)
+}
+
+// MarkFlagsRequiredTogether marks the given flags with annotations so that
+// an error is returned if the command is invoked with a subset (but not all)
+// of the given flags.
+func (c *Cmd) MarkFlagsRequiredTogether(names ...string) error {
+	return c.markFlagGroup(FlagGroupRequiredTogether, names)
+}
+
+// MarkFlagsMutuallyExclusive marks the given flags with annotations so that
+// an error is returned if the command is invoked with more than one flag
+// from the given set of flags.
+func (c *Cmd) MarkFlagsMutuallyExclusive(names ...string) error {
+	return c.markFlagGroup(FlagGroupMutuallyExclusive, names)
+}
+
+// MarkFlagsOneRequired marks the given flags with annotations so that an
+// error is returned if the command is invoked without at least one flag
+// from the given set of flags.
+func (c *Cmd) MarkFlagsOneRequired(names ...string) error {
+	return c.markFlagGroup(FlagGroupOneRequired, names)
+}
+
+// HasFlagGroups determines if any flag of the command belongs to a group
+func (c *Cmd) HasFlagGroups() bool {
+	return len(c.flagGroups()) > 0
+}
+
+// FlagGroupUsages returns the help text describing the flag groups
+func (c *Cmd) FlagGroupUsages() string {
+	groups := c.flagGroups()
+
+	type line struct{ flags, desc string }
+	var lines []line
+	maxLen := 0
+	for _, kind := range flagGroupKinds {
+		for _, group := range groups[kind] {
+			var names []string
+			for _, name := range strings.Split(group, " ") {
+				names = append(names, "--"+name)
+			}
+
+			l := line{flags: strings.Join(names, ", "), desc: flagGroupDescriptions[kind]}
+			if len(l.flags) > maxLen {
+				maxLen = len(l.flags)
+			}
+			lines = append(lines, l)
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	for _, l := range lines {
+		_, _ = fmt.Fprintf(buf, "  %s   %s\n", rpad(l.flags, maxLen), l.desc)
+	}
+
+	return buf.String()
+}
+
+// ValidateFlagGroups validates the flag groups of the command, returning a
+// FlagGroupError for the first group whose constraint is not met.
+func (c *Cmd) ValidateFlagGroups() error {
+	if c.DisableFlagParsing {
+		return nil
+	}
+
+	flags := c.Flags()
+	groups := c.flagGroups()
+	for _, kind := range flagGroupKinds {
+		for _, group := range groups[kind] {
+			names := strings.Split(group, " ")
+			var set, unset []string
+			for _, name := range names {
+				if flags.Changed(name) {
+					set = append(set, name)
+				} else {
+					unset = append(unset, name)
+				}
+			}
+
+			var reason string
+			switch {
+			case kind == FlagGroupRequiredTogether && len(set) > 0 && len(unset) > 0:
+				reason = fmt.Sprintf("if any flags in the group %v are set they must all be set; missing %v", names, unset)
+			case kind == FlagGroupMutuallyExclusive && len(set) > 1:
+				reason = fmt.Sprintf("if any flags in the group %v are set none of the others can be; %v were all set", names, set)
+			case kind == FlagGroupOneRequired && len(set) == 0:
+				reason = fmt.Sprintf("at least one of the flags in the group %v is required", names)
+			default:
+				continue
+			}
+
+			return &FlagGroupError{Path: c.Path(), Kind: kind, Group: names, Reason: reason}
+		}
+	}
+
+	return nil
+}
+
+// flagGroupExclusions returns the names of the flags which can not be used
+// because a flag of the same mutually exclusive group is already set.
+func (c *Cmd) flagGroupExclusions() map[string]bool {
+	flags := c.Flags()
+	excluded := map[string]bool{}
+	for _, group := range c.flagGroups()[FlagGroupMutuallyExclusive] {
+		names := strings.Split(group, " ")
+		for _, name := range names {
+			if !flags.Changed(name) {
+				continue
+			}
+
+			for _, other := range names {
+				if other != name {
+					excluded[other] = true
+				}
+			}
+		}
+	}
+
+	return excluded
+}
+
+// flagGroupRequirements returns the names of the flags which the groups
+// currently require: the rest of a required together group once one is set,
+// and every flag of a one required group while none is set.
+func (c *Cmd) flagGroupRequirements() map[string]bool {
+	flags := c.Flags()
+	groups := c.flagGroups()
+	required := map[string]bool{}
+
+	for _, kind := range []string{FlagGroupRequiredTogether, FlagGroupOneRequired} {
+		for _, group := range groups[kind] {
+			names := strings.Split(group, " ")
+			anySet := false
+			for _, name := range names {
+				anySet = anySet || flags.Changed(name)
+			}
+
+			if anySet == (kind == FlagGroupRequiredTogether) {
+				for _, name := range names {
+					required[name] = true
+				}
+			}
+		}
+	}
+
+	return required
+}
+
+// flagGroups collects the distinct groups of the command by annotation.
+// Groups with flags which are not defined on the command are ignored.
+func (c *Cmd) flagGroups() map[string][]string {
+	c.mergeGlobalFlags()
+	flags := c.Flags()
+
+	seen := map[string]bool{}
+	groups := map[string][]string{}
+	flags.VisitAll(func(f *flag.Flag) {
+		for _, kind := range flagGroupKinds {
+			for _, group := range f.Annotations[kind] {
+				key := kind + "/" + group
+				if seen[key] || !hasAllFlags(flags, strings.Split(group, " ")...) {
+					continue
+				}
+				seen[key] = true
+				groups[kind] = append(groups[kind], group)
+			}
+		}
+	})
+
+	for kind := range groups {
+		sort.Strings(groups[kind])
+	}
+
+	return groups
+}
+
+func (c *Cmd) markFlagGroup(kind string, names []string) error {
+	if len(names) < 2 {
+		return failure.InvalidParam("flag group needs at least 2 flags, got %v", names)
+	}
+
+	c.mergeGlobalFlags()
+	flags := c.Flags()
+	for _, name := range names {
+		if flags.Lookup(name) == nil {
+			return failure.NotFound("flag (%s), does not exist on (%s)", name, c.Name())
+		}
+	}
+
+	group := strings.Join(names, " ")
+	for _, name := range names {
+		f := flags.Lookup(name)
+		// Each time this is called is a single new entry; this allows it to
+		// be a member of multiple groups if needed.
+		if stringInSlice(group, f.Annotations[kind]) {
+			continue
+		}
+
+		values := append(f.Annotations[kind], group)
+		if err := flags.SetAnnotation(name, kind, values); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func hasAllFlags(fs *flag.FlagSet, names ...string) bool {
+	for _, name := range names {
+		if fs.Lookup(name) == nil {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,125 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// newFlagGroupTree builds app, with a global --debug, holding sub, whose
+// flags are in one group of every kind, one of them holding --debug
+func newFlagGroupTree(t *testing.T) *Cmd {
+	root := &Cmd{Use: "app"}
+	root.GlobalFlags().Bool("debug", false, "debug")
+	sub := &Cmd{Use: "sub"}
+	for _, name := range []string{"user", "pass", "json", "yaml", "trace", "file", "url"} {
+		sub.Flags().String(name, "", name)
+	}
+	sub.SetRun(func(c *Cmd, args []string) error { return nil })
+	other := &Cmd{Use: "other"}
+	other.SetRun(func(c *Cmd, args []string) error { return nil })
+	root.Add(sub, other)
+
+	for _, err := range []error{
+		sub.MarkFlagsRequiredTogether("user", "pass"),
+		sub.MarkFlagsMutuallyExclusive("json", "yaml"),
+		sub.MarkFlagsMutuallyExclusive("debug", "trace"),
+		sub.MarkFlagsOneRequired("file", "url"),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	root.SetOutputStream(&bytes.Buffer{})
+	root.SetErrorStream(&bytes.Buffer{})
+
+	return root
+}
+
+func TestValidateFlagGroups(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		kind string
+	}{
+		{name: "satisfied", args: []string{"sub", "--file", "f"}},
+		{name: "together", args: []string{"sub", "--file", "f", "--user", "u", "--pass", "p"}},
+		{name: "together missing one", args: []string{"sub", "--file", "f", "--user", "u"}, kind: FlagGroupRequiredTogether},
+		{name: "exclusive", args: []string{"sub", "--file", "f", "--json", "j", "--yaml", "y"}, kind: FlagGroupMutuallyExclusive},
+		{name: "one required", args: []string{"sub"}, kind: FlagGroupOneRequired},
+		{name: "one required by the other", args: []string{"sub", "--url", "u"}},
+		{name: "exclusive with a global flag", args: []string{"--debug", "sub", "--file", "f", "--trace", "t"}, kind: FlagGroupMutuallyExclusive},
+		{name: "global flag alone", args: []string{"sub", "--debug", "--file", "f"}},
+		{name: "other command", args: []string{"other", "--debug"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := newFlagGroupTree(t)
+			root.SetArgs(tt.args)
+
+			err := root.Execute()
+			if tt.kind == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			var e *FlagGroupError
+			if !errors.As(err, &e) {
+				t.Fatalf("expected a FlagGroupError, got %v", err)
+			}
+
+			if e.Kind != tt.kind || e.Path != "app sub" {
+				t.Errorf("error = %+v, want kind %s for app sub", e, tt.kind)
+			}
+		})
+	}
+}
+
+func TestFlagGroupCompletions(t *testing.T) {
+	tests := []struct {
+		name  string
+		args  []string
+		first []string
+		skip  []string
+	}{
+		{name: "one required first", args: []string{"sub", "--"}, first: []string{"--file", "--url"}},
+		{name: "together first", args: []string{"sub", "--url", "u", "--user", "x", "--"}, first: []string{"--pass"}},
+		{name: "exclusive skipped", args: []string{"sub", "--url", "u", "--json", "j", "--"}, skip: []string{"--yaml"}},
+		{name: "exclusive global skipped", args: []string{"sub", "--url", "u", "--debug", "--"}, skip: []string{"--trace"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := newFlagGroupTree(t)
+			out := &bytes.Buffer{}
+			root.SetOutputStream(out)
+			root.SetArgs(append([]string{ShellCompNoDescRequestCmd}, tt.args...))
+			if err := root.Execute(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var completions []string
+			for _, line := range strings.Split(out.String(), "\n") {
+				if line != "" && !strings.HasPrefix(line, ":") {
+					completions = append(completions, line)
+				}
+			}
+
+			if n := len(tt.first); n > 0 && (len(completions) < n || !reflect.DeepEqual(completions[:n], tt.first)) {
+				t.Errorf("completions = %q, want them to start with %q", completions, tt.first)
+			}
+
+			for _, name := range tt.skip {
+				if stringInSlice(name, completions) {
+					t.Errorf("completions = %q, want %s skipped", completions, name)
+				}
+			}
+		})
+	}
+}
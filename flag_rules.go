@@ -0,0 +1,184 @@
+package cli
+
+import (
+	"fmt"
+	"github.com/rsb/failure"
+	flag "github.com/rsb/pflag"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// FlagRule validates the value of a flag once the flags are set, after the
+// args, the environment and the config were applied and before the
+// Lifecycle is fired. The default of a flag is not checked. The rules of a
+// flag are described in help.
+// Description:	what a valid value is, like "between 1 and 10"
+// Check:	returns an error when value is invalid, slices are checked item by item
+type FlagRule struct {
+	Description string
+	Check       func(value string) error
+}
+
+// FlagRuleError is returned, in a failure.Multi with the other failures,
+// when the value of a flag breaks one of its rules.
+type FlagRuleError struct {
+	Path  string
+	Flag  string
+	Value string
+	Err   error
+}
+
+func (e *FlagRuleError) Error() string {
+	return fmt.Sprintf("%s: invalid value %q for flag (%s): %v", e.Path, e.Value, e.Flag, e.Err)
+}
+
+// Unwrap allows failure.IsInvalidParam to identify the error
+func (e *FlagRuleError) Unwrap() error {
+	return failure.InvalidParam("flag (%s) of (%s) breaks its rules", e.Flag, e.Path)
+}
+
+// RangeRule accepts numbers between min and max, inclusive
+func RangeRule(min, max float64) FlagRule {
+	return FlagRule{
+		Description: fmt.Sprintf("between %g and %g", min, max),
+		Check: func(value string) error {
+			n, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return fmt.Errorf("not a number")
+			}
+
+			if n < min || n > max {
+				return fmt.Errorf("not between %g and %g", min, max)
+			}
+
+			return nil
+		},
+	}
+}
+
+// MustRegexpRule accepts values matching pattern. It panics when pattern
+// does not compile, like regexp.MustCompile.
+func MustRegexpRule(pattern string) FlagRule {
+	re := regexp.MustCompile(pattern)
+	return FlagRule{
+		Description: "matching " + pattern,
+		Check: func(value string) error {
+			if !re.MatchString(value) {
+				return fmt.Errorf("does not match %s", pattern)
+			}
+
+			return nil
+		},
+	}
+}
+
+// PathExistsRule accepts paths of existing files or directories
+func PathExistsRule() FlagRule {
+	return FlagRule{
+		Description: "an existing path",
+		Check: func(value string) error {
+			if _, err := os.Stat(value); err != nil {
+				return fmt.Errorf("path does not exist")
+			}
+
+			return nil
+		},
+	}
+}
+
+// CustomRule accepts the values for which check returns no error
+func CustomRule(description string, check func(value string) error) FlagRule {
+	return FlagRule{Description: description, Check: check}
+}
+
+// AddFlagRules attaches rules to the named flag. Rules attached to a global
+// flag also apply to the subcommands.
+func (c *Cmd) AddFlagRules(name string, rules ...FlagRule) error {
+	c.mergeGlobalFlags()
+	if c.Flags().Lookup(name) == nil {
+		return failure.NotFound("flag (%s), does not exist on (%s)", name, c.Name())
+	}
+
+	if c.flagRules == nil {
+		c.flagRules = map[string][]FlagRule{}
+	}
+
+	c.flagRules[name] = append(c.flagRules[name], rules...)
+	return nil
+}
+
+// FlagRules returns the rules applying to the named flag of the command,
+// including the ones attached to global flags by the parents.
+func (c *Cmd) FlagRules(name string) []FlagRule {
+	f := c.Flags().Lookup(name)
+	if f == nil {
+		return nil
+	}
+
+	var rules []FlagRule
+	for p := c; p != nil; p = p.Parent() {
+		if p != c && p.GlobalFlags().Lookup(name) != f {
+			continue
+		}
+
+		rules = append(rules, p.flagRules[name]...)
+	}
+
+	return rules
+}
+
+// ValidateFlagRules checks every flag which was set against its rules,
+// returning all the failures at once in a failure.Multi of FlagRuleError.
+// Only flags set by the args, the environment, the config or a secret's
+// stdin and file flags are checked, defaults are trusted and never validated.
+func (c *Cmd) ValidateFlagRules() error {
+	if c.DisableFlagParsing {
+		return nil
+	}
+
+	var errs *failure.Multi
+	c.Flags().VisitAll(func(f *flag.Flag) {
+		rules := c.FlagRules(f.Name)
+		if !f.Changed || len(rules) == 0 {
+			return
+		}
+
+		values := []string{f.Value.String()}
+		if s, ok := f.Value.(flag.SliceValue); ok {
+			values = s.GetSlice()
+		}
+
+		for _, rule := range rules {
+			for _, v := range values {
+				if err := rule.Check(v); err != nil {
+					errs = failure.Append(errs, &FlagRuleError{
+						Path:  c.Path(),
+						Flag:  f.Name,
+						Value: redactFlagValue(f, v),
+						Err:   err,
+					})
+				}
+			}
+		}
+	})
+
+	return errs.ErrorOrNil()
+}
+
+// flagRulesUsage describes the rules of f for help
+func (c *Cmd) flagRulesUsage(f *flag.Flag) string {
+	var descriptions []string
+	for _, rule := range c.FlagRules(f.Name) {
+		if rule.Description != "" {
+			descriptions = append(descriptions, rule.Description)
+		}
+	}
+
+	if len(descriptions) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("(valid: %s)", strings.Join(descriptions, ", "))
+}
@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"github.com/rsb/failure"
+	"reflect"
+	"testing"
+)
+
+func TestValidateFlagRules(t *testing.T) {
+	type failed struct {
+		path, flag, value string
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		want []failed
+	}{
+		{name: "defaults are not checked", args: []string{"sub"}},
+		{name: "valid", args: []string{"sub", "--port", "8", "--name", "abc", "--tags", "a,b", "--level", "2"}},
+		{
+			name: "every failure at once",
+			args: []string{"sub", "--port", "20", "--name", "X1"},
+			want: []failed{{"app sub", "name", "X1"}, {"app sub", "port", "20"}},
+		},
+		{
+			name: "slice items",
+			args: []string{"sub", "--tags", "a,B,c,D"},
+			want: []failed{{"app sub", "tags", "B"}, {"app sub", "tags", "D"}},
+		},
+		{
+			name: "rules of a parent's global flag",
+			args: []string{"sub", "--level", "5"},
+			want: []failed{{"app sub", "level", "5"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := &Cmd{Use: "app"}
+			root.GlobalFlags().Int("level", 9, "level")
+			sub := &Cmd{Use: "sub"}
+			sub.Flags().Int("port", 50, "port")
+			sub.Flags().String("name", "", "name")
+			sub.Flags().StringSlice("tags", []string{"DEFAULT"}, "tags")
+			sub.SetRun(func(c *Cmd, args []string) error { return nil })
+			root.Add(sub)
+
+			for _, err := range []error{
+				root.AddFlagRules("level", RangeRule(0, 3)),
+				sub.AddFlagRules("port", RangeRule(1, 10)),
+				sub.AddFlagRules("name", MustRegexpRule("^[a-z]+$")),
+				sub.AddFlagRules("tags", MustRegexpRule("^[a-z]$")),
+			} {
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			root.SetOutputStream(&bytes.Buffer{})
+			root.SetErrorStream(&bytes.Buffer{})
+			root.SetArgs(tt.args)
+
+			err := root.Execute()
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			var multi *failure.Multi
+			if !errors.As(err, &multi) {
+				t.Fatalf("expected a failure.Multi, got %v", err)
+			}
+
+			var got []failed
+			for _, e := range multi.WrappedErrors() {
+				var ruleErr *FlagRuleError
+				if !errors.As(e, &ruleErr) {
+					t.Fatalf("expected a FlagRuleError, got %v", e)
+				}
+				got = append(got, failed{ruleErr.Path, ruleErr.Flag, ruleErr.Value})
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("failures = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,278 @@
+package cli
+
+import (
+	"github.com/rsb/failure"
+	flag "github.com/rsb/pflag"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Struct tags understood by BindFlags
+const (
+	FlagTagName       = "flag"
+	FlagTagShorthand  = "short"
+	FlagTagUsage      = "usage"
+	FlagTagDefault    = "default"
+	FlagTagEnv        = "env"
+	FlagTagRequired   = "required"
+	FlagTagHidden     = "hidden"
+	FlagTagDeprecated = "deprecated"
+	FlagTagNegatable  = "negatable"
+)
+
+// BindFlags defines a flag in Flags for every exported field of the struct v
+// points to. The flags write directly into the fields so the struct is
+// populated when the flags are parsed, before the Lifecycle is fired.
+//
+//	type opts struct {
+//		Host    string        `flag:"host" short:"H" usage:"server host" default:"localhost"`
+//		Timeout time.Duration `usage:"request timeout" env:"APP_TIMEOUT"`
+//		Tags    []string      `usage:"tags to apply"`
+//		Labels  map[string]string
+//		DB      struct {
+//			User     string `usage:"database user" required:"true"`
+//			Password string `hidden:"true"`
+//		} `flag:"db"`
+//	}
+//
+// The flag name defaults to the field name in kebab case and fields tagged
+// with `flag:"-"` are skipped. Nested structs are groups whose flags are
+// prefixed with the name of the struct field, db-user and db-password above,
+// while embedded structs are flattened. The env tag is a comma separated list
+// of environment variables bound to the flag with BindFlagEnv and a bool
+// field tagged `negatable:"true"` also accepts --no-<name>.
+func (c *Cmd) BindFlags(v interface{}) error {
+	return BindFlags(c.Flags(), v)
+}
+
+// BindGlobalFlags is BindFlags for the GlobalFlags of the command
+func (c *Cmd) BindGlobalFlags(v interface{}) error {
+	return BindFlags(c.GlobalFlags(), v)
+}
+
+// BindFlags defines a flag in flags for every exported field of the struct v
+// points to. See Cmd.BindFlags for the tags.
+func BindFlags(flags *flag.FlagSet, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return failure.InvalidParam("BindFlags expects a pointer to a struct, got (%T)", v)
+	}
+
+	return bindFlagFields(flags, rv.Elem(), "")
+}
+
+func bindFlagFields(flags *flag.FlagSet, rv reflect.Value, prefix string) error {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		// an embedded struct of an unexported type still promotes its
+		// exported fields
+		embedded := sf.Anonymous && sf.Type.Kind() == reflect.Struct
+		if !sf.IsExported() && !embedded {
+			continue
+		}
+
+		name, ok := sf.Tag.Lookup(FlagTagName)
+		if name == "-" {
+			continue
+		}
+		if !ok || name == "" {
+			name = kebabCase(sf.Name)
+		}
+
+		field := rv.Field(i)
+		if isFlagGroup(field, sf) {
+			groupPrefix := prefix + name + "-"
+			if sf.Anonymous && !ok {
+				groupPrefix = prefix
+			}
+
+			if err := bindFlagFields(flags, field, groupPrefix); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := bindFlagField(flags, field, sf, prefix+name); err != nil {
+			return failure.Wrap(err, "BindFlags field (%s)", sf.Name)
+		}
+	}
+
+	return nil
+}
+
+// isFlagGroup determines if the field is a struct holding flags rather than
+// a flag.Value
+func isFlagGroup(field reflect.Value, sf reflect.StructField) bool {
+	if field.Kind() != reflect.Struct {
+		return false
+	}
+
+	if !sf.IsExported() {
+		return true
+	}
+
+	_, isValue := field.Addr().Interface().(flag.Value)
+	return !isValue
+}
+
+func bindFlagField(flags *flag.FlagSet, field reflect.Value, sf reflect.StructField, name string) error {
+	ptr := field.Addr().Interface()
+	short := sf.Tag.Get(FlagTagShorthand)
+	usage := sf.Tag.Get(FlagTagUsage)
+
+	def, hasDefault := sf.Tag.Lookup(FlagTagDefault)
+
+	// The default is parsed by a scratch flag writing into the field so the
+	// real flag is defined with it and reports it in help.
+	if hasDefault {
+		scratch := newFlagSet(name)
+		if err := defineFlag(scratch, ptr, name, "", usage); err != nil {
+			return err
+		}
+
+		if err := scratch.Set(name, def); err != nil {
+			return failure.InvalidParam("invalid default %q for flag (%s): %v", def, name, err)
+		}
+	}
+
+	if err := defineFlag(flags, ptr, name, short, usage); err != nil {
+		return err
+	}
+
+	// the marks are applied in order so the same error is always reported
+	marks := []struct {
+		tag  string
+		mark func() error
+	}{
+		{tag: FlagTagRequired, mark: func() error { return MarkFlagRequired(flags, name) }},
+		{tag: FlagTagHidden, mark: func() error { return flags.MarkHidden(name) }},
+		{tag: FlagTagNegatable, mark: func() error { return MarkFlagNegatable(flags, name) }},
+	}
+	for _, m := range marks {
+		tag := m.tag
+		raw, ok := sf.Tag.Lookup(tag)
+		if !ok {
+			continue
+		}
+
+		enabled, err := strconv.ParseBool(raw)
+		if err != nil {
+			return failure.InvalidParam("invalid %s tag %q for flag (%s)", tag, raw, name)
+		}
+
+		if !enabled {
+			continue
+		}
+
+		if err := m.mark(); err != nil {
+			return err
+		}
+	}
+
+	if env := sf.Tag.Get(FlagTagEnv); env != "" {
+		if err := BindFlagEnv(flags, name, strings.Split(env, ",")...); err != nil {
+			return err
+		}
+	}
+
+	if msg := sf.Tag.Get(FlagTagDeprecated); msg != "" {
+		return flags.MarkDeprecated(name, msg)
+	}
+
+	return nil
+}
+
+// defineFlag defines a flag writing into ptr using its current value as the
+// default.
+func defineFlag(flags *flag.FlagSet, ptr interface{}, name, short, usage string) error {
+	switch p := ptr.(type) {
+	case flag.Value:
+		flags.VarP(p, name, short, usage)
+	case *string:
+		flags.StringVarP(p, name, short, *p, usage)
+	case *bool:
+		flags.BoolVarP(p, name, short, *p, usage)
+	case *int:
+		flags.IntVarP(p, name, short, *p, usage)
+	case *int8:
+		flags.Int8VarP(p, name, short, *p, usage)
+	case *int16:
+		flags.Int16VarP(p, name, short, *p, usage)
+	case *int32:
+		flags.Int32VarP(p, name, short, *p, usage)
+	case *int64:
+		flags.Int64VarP(p, name, short, *p, usage)
+	case *uint:
+		flags.UintVarP(p, name, short, *p, usage)
+	case *uint8:
+		flags.Uint8VarP(p, name, short, *p, usage)
+	case *uint16:
+		flags.Uint16VarP(p, name, short, *p, usage)
+	case *uint32:
+		flags.Uint32VarP(p, name, short, *p, usage)
+	case *uint64:
+		flags.Uint64VarP(p, name, short, *p, usage)
+	case *float32:
+		flags.Float32VarP(p, name, short, *p, usage)
+	case *float64:
+		flags.Float64VarP(p, name, short, *p, usage)
+	case *time.Duration:
+		flags.DurationVarP(p, name, short, *p, usage)
+	case *net.IP:
+		flags.IPVarP(p, name, short, *p, usage)
+	case *[]string:
+		flags.StringSliceVarP(p, name, short, *p, usage)
+	case *[]bool:
+		flags.BoolSliceVarP(p, name, short, *p, usage)
+	case *[]int:
+		flags.IntSliceVarP(p, name, short, *p, usage)
+	case *[]int32:
+		flags.Int32SliceVarP(p, name, short, *p, usage)
+	case *[]int64:
+		flags.Int64SliceVarP(p, name, short, *p, usage)
+	case *[]uint:
+		flags.UintSliceVarP(p, name, short, *p, usage)
+	case *[]float32:
+		flags.Float32SliceVarP(p, name, short, *p, usage)
+	case *[]float64:
+		flags.Float64SliceVarP(p, name, short, *p, usage)
+	case *[]time.Duration:
+		flags.DurationSliceVarP(p, name, short, *p, usage)
+	case *[]net.IP:
+		flags.IPSliceVarP(p, name, short, *p, usage)
+	case *map[string]string:
+		flags.StringToStringVarP(p, name, short, *p, usage)
+	case *map[string]int:
+		flags.StringToIntVarP(p, name, short, *p, usage)
+	case *map[string]int64:
+		flags.StringToInt64VarP(p, name, short, *p, usage)
+	default:
+		return failure.InvalidParam("unsupported type (%s) for flag (%s)", reflect.TypeOf(ptr).Elem(), name)
+	}
+
+	return nil
+}
+
+// kebabCase converts a field name to a flag name, HTTPPort becomes http-port
+func kebabCase(s string) string {
+	runes := []rune(s)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			startsWord := i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]) ||
+				(i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1])))
+			if startsWord {
+				b.WriteByte('-')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
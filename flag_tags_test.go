@@ -0,0 +1,143 @@
+package cli
+
+import (
+	flag "github.com/rsb/pflag"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type tagsCommon struct {
+	Color bool `negatable:"true" default:"true"`
+}
+
+type tagsOpts struct {
+	tagsCommon
+	Host     string        `flag:"host" short:"H" usage:"server host" default:"localhost"`
+	HTTPPort int           `default:"8080"`
+	Timeout  time.Duration `env:"APP_TIMEOUT,TIMEOUT"`
+	Tags     []string      `default:"a,b"`
+	Labels   map[string]string
+	Skipped  string `flag:"-"`
+	DB       struct {
+		User     string `required:"true"`
+		Password string `hidden:"true"`
+		TLS      struct {
+			Cert string
+		}
+	} `flag:"db"`
+	Old string `deprecated:"use --host"`
+}
+
+func TestBindFlags(t *testing.T) {
+	var opts tagsOpts
+	cmd := &Cmd{Use: "app"}
+	if err := cmd.BindFlags(&opts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var names []string
+	cmd.Flags().VisitAll(func(f *flag.Flag) {
+		names = append(names, f.Name)
+	})
+	want := []string{
+		"color", "db-password", "db-tls-cert", "db-user", "host", "http-port",
+		"labels", "no-color", "old", "tags", "timeout",
+	}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("flags = %q, want %q", names, want)
+	}
+
+	// the defaults are written into the fields and reported by the flags
+	if opts.Host != "localhost" || opts.HTTPPort != 8080 || !opts.Color || !reflect.DeepEqual(opts.Tags, []string{"a", "b"}) {
+		t.Errorf("defaults not set on the fields: %+v", opts)
+	}
+	for name, def := range map[string]string{"host": "localhost", "http-port": "8080", "color": "true", "tags": "[a,b]"} {
+		if got := cmd.Flags().Lookup(name).Default; got != def {
+			t.Errorf("flag (%s) default = %q, want %q", name, got, def)
+		}
+	}
+
+	if f := cmd.Flags().Lookup("host"); f.Short != "H" || f.Usage != "server host" {
+		t.Errorf("host flag = %+v", f)
+	}
+	if !isFlagRequired(cmd.Flags().Lookup("db-user")) {
+		t.Error("expected db-user to be required")
+	}
+	if !cmd.Flags().Lookup("db-password").Hidden {
+		t.Error("expected db-password to be hidden")
+	}
+	if cmd.Flags().Lookup("old").Deprecated != "use --host" {
+		t.Error("expected old to be deprecated")
+	}
+	if got := cmd.Flags().Lookup("timeout").Annotations[FlagAnnotationEnv]; !reflect.DeepEqual(got, []string{"APP_TIMEOUT", "TIMEOUT"}) {
+		t.Errorf("timeout env = %q", got)
+	}
+
+	err := cmd.Flags().Parse([]string{
+		"-H", "example.com", "--no-color", "--tags", "x", "--labels", "env=prod",
+		"--db-user", "admin", "--db-tls-cert", "c.pem", "--timeout", "2s",
+	})
+	if err != nil {
+		t.Fatalf("unexpected parse error: %v", err)
+	}
+
+	if opts.Host != "example.com" || opts.Color || opts.DB.User != "admin" || opts.DB.TLS.Cert != "c.pem" ||
+		opts.Timeout != 2*time.Second || !reflect.DeepEqual(opts.Tags, []string{"x"}) ||
+		!reflect.DeepEqual(opts.Labels, map[string]string{"env": "prod"}) {
+		t.Errorf("fields not written by the flags: %+v", opts)
+	}
+}
+
+func TestBindFlags_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		v    interface{}
+		err  string
+	}{
+		{name: "not a pointer", v: struct{}{}, err: "expects a pointer to a struct"},
+		{name: "not a struct", v: new(string), err: "expects a pointer to a struct"},
+		{name: "unsupported type", v: &struct{ Ch chan int }{}, err: "unsupported type (chan int) for flag (ch)"},
+		{name: "invalid default", v: &struct {
+			Port int `default:"http"`
+		}{}, err: "invalid default \"http\" for flag (port)"},
+		{name: "invalid bool tag", v: &struct {
+			Name string `required:"yes"`
+		}{}, err: "invalid required tag \"yes\" for flag (name)"},
+		{name: "tags in order", v: &struct {
+			Name string `required:"yes" hidden:"no" negatable:"x"`
+		}{}, err: "invalid required tag"},
+		{name: "negatable string", v: &struct {
+			Name string `negatable:"true"`
+		}{}, err: "only bool flags are negatable"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := BindFlags(newFlagSet("app"), tt.v)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("BindFlags() error = %v, want it to contain %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestKebabCase(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{in: "Host", want: "host"},
+		{in: "HTTPPort", want: "http-port"},
+		{in: "MaxRetries", want: "max-retries"},
+		{in: "UserID", want: "user-id"},
+		{in: "Level2Cache", want: "level2-cache"},
+		{in: "URL", want: "url"},
+	}
+
+	for _, tt := range tests {
+		if got := kebabCase(tt.in); got != tt.want {
+			t.Errorf("kebabCase(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
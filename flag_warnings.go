@@ -0,0 +1,89 @@
+package cli
+
+import (
+	"fmt"
+	"github.com/rsb/failure"
+	"strings"
+)
+
+// FlagWarningPolicy determines what happens to the warnings raised while the
+// flags are set, like the use of a deprecated flag or shorthand.
+type FlagWarningPolicy int
+
+const (
+	// FlagWarningsPrint prints each distinct warning once to ErrorStream
+	FlagWarningsPrint FlagWarningPolicy = iota
+
+	// FlagWarningsSuppress drops the warnings, they are still available from
+	// FlagWarnings
+	FlagWarningsSuppress
+
+	// FlagWarningsStrict fails the execution with a FlagWarningsError
+	FlagWarningsStrict
+)
+
+// FlagWarningsError is returned when the flags raised warnings and the
+// FlagWarningsStrict policy is in effect.
+type FlagWarningsError struct {
+	Path     string
+	Warnings []string
+}
+
+func (e *FlagWarningsError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, strings.Join(e.Warnings, "; "))
+}
+
+// Unwrap allows failure.IsInvalidParam to identify the error
+func (e *FlagWarningsError) Unwrap() error {
+	return failure.InvalidParam("flag warnings for (%s)", e.Path)
+}
+
+// SetFlagWarningPolicy assigns what happens to flag warnings for the whole
+// command tree. It is stored on the root command.
+func (c *Cmd) SetFlagWarningPolicy(p FlagWarningPolicy) {
+	c.Root().flagWarningPolicy = p
+}
+
+// FlagWarningPolicy returns the flag warning policy of the command tree
+func (c *Cmd) FlagWarningPolicy() FlagWarningPolicy {
+	return c.Root().flagWarningPolicy
+}
+
+// FlagWarnings returns the distinct warnings raised while setting the flags
+// during the last execution of the command, in the order they were raised.
+func (c *Cmd) FlagWarnings() []string {
+	return c.flagWarnings
+}
+
+// collectFlagWarnings moves the warnings written by the flag sets to the
+// error buffer into the warnings of the command.
+func (c *Cmd) collectFlagWarnings() {
+	errorBuf := c.flags.LoadErrorBufferWhenEmpty()
+	for _, line := range strings.Split(errorBuf.String(), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !stringInSlice(line, c.flagWarnings) {
+			c.flagWarnings = append(c.flagWarnings, line)
+		}
+	}
+	errorBuf.Reset()
+}
+
+// reportFlagWarnings applies the flag warning policy
+func (c *Cmd) reportFlagWarnings() error {
+	c.collectFlagWarnings()
+	if len(c.flagWarnings) == 0 {
+		return nil
+	}
+
+	switch c.FlagWarningPolicy() {
+	case FlagWarningsSuppress:
+		return nil
+	case FlagWarningsStrict:
+		return &FlagWarningsError{Path: c.Path(), Warnings: c.flagWarnings}
+	default:
+		for _, w := range c.flagWarnings {
+			c.PrintErrln(w)
+		}
+		return nil
+	}
+}
@@ -4,4 +4,4 @@ go 1.18
 
 require github.com/rsb/pflag v0.0.0-20220611151008-c9411556af72
 
-require github.com/rsb/failure v0.14.0 // indirect
+require github.com/rsb/failure v0.14.0
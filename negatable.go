@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"github.com/rsb/failure"
+	flag "github.com/rsb/pflag"
+	"strconv"
+)
+
+// FlagAnnotationNegates is set on the --no-<name> flag of a negatable flag,
+// its value is the name of the flag it negates.
+const FlagAnnotationNegates = "cli_annotation_flag_negates"
+
+// negatedPrefix prefixes the name of the flag setting a negatable flag to false
+const negatedPrefix = "no-"
+
+// NegatableBoolVarP defines a bool flag which is also set to false with
+// --no-<name>. The flag is shown in help once, as --[no-]<name>.
+func NegatableBoolVarP(flags *flag.FlagSet, p *bool, name, short string, value bool, usage string) {
+	flags.BoolVarP(p, name, short, value, usage)
+	if err := MarkFlagNegatable(flags, name); err != nil {
+		panic(err)
+	}
+}
+
+// MarkFlagNegatable allows the named bool flag to be set to false with
+// --no-<name>, see MarkFlagNegatable
+func (c *Cmd) MarkFlagNegatable(name string) error {
+	return MarkFlagNegatable(c.Flags(), name)
+}
+
+// MarkGlobalFlagNegatable allows the named global bool flag to be set to
+// false with --no-<name>, see MarkFlagNegatable
+func (c *Cmd) MarkGlobalFlagNegatable(name string) error {
+	return MarkFlagNegatable(c.GlobalFlags(), name)
+}
+
+// MarkFlagNegatable allows the named bool flag to be set to false with
+// --no-<name>. The negation is a hidden flag writing into the named flag, so
+// either form on the command line takes precedence over the environment and
+// config, which only ever set the named flag.
+func MarkFlagNegatable(flags *flag.FlagSet, name string) error {
+	f := flags.Lookup(name)
+	if f == nil {
+		return failure.NotFound("flag (%s)", name)
+	}
+
+	if f.Value.Type() != "bool" {
+		return failure.InvalidParam("flag (%s) is a %s, only bool flags are negatable", name, f.Value.Type())
+	}
+
+	negated := negatedPrefix + name
+	if flags.Lookup(negated) != nil {
+		return nil
+	}
+
+	flags.Var(&negatedBool{flags: flags, name: name}, negated, "set --"+name+" to false")
+	nf := flags.Lookup(negated)
+	nf.NoOptDefVal = "true"
+	nf.Hidden = true
+
+	return flags.SetAnnotation(negated, FlagAnnotationNegates, []string{name})
+}
+
+// negatedFlag returns the name of the flag f negates
+func negatedFlag(f *flag.Flag) (string, bool) {
+	names, ok := f.Annotations[FlagAnnotationNegates]
+	if !ok || len(names) == 0 {
+		return "", false
+	}
+
+	return names[0], true
+}
+
+// isFlagNegatable determines if f has a --no-<name> flag in flags
+func isFlagNegatable(flags *flag.FlagSet, f *flag.Flag) bool {
+	nf := flags.Lookup(negatedPrefix + f.Name)
+	if nf == nil {
+		return false
+	}
+
+	name, ok := negatedFlag(nf)
+	return ok && name == f.Name
+}
+
+// negatedBool is the value of a --no-<name> flag, it sets the named flag to
+// the opposite of its own value.
+type negatedBool struct {
+	flags *flag.FlagSet
+	name  string
+}
+
+func (b *negatedBool) String() string {
+	f := b.flags.Lookup(b.name)
+	if f == nil {
+		return "false"
+	}
+
+	return strconv.FormatBool(f.Value.String() == "false")
+}
+
+func (b *negatedBool) Set(s string) error {
+	v, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+
+	return b.flags.Set(b.name, strconv.FormatBool(!v))
+}
+
+func (b *negatedBool) Type() string {
+	return "bool"
+}
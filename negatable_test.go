@@ -0,0 +1,89 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNegatableFlag_OverridesEnvAndConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "negatable.json")
+	if err := os.WriteFile(path, []byte(`{"cache": true}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		env    string
+		config bool
+		args   []string
+		want   bool
+	}{
+		{name: "default", args: []string{}, want: false},
+		{name: "flag", args: []string{"--cache"}, want: true},
+		{name: "env", env: "true", args: []string{}, want: true},
+		{name: "env negated", env: "true", args: []string{"--no-cache"}, want: false},
+		{name: "config", config: true, args: []string{}, want: true},
+		{name: "config negated", config: true, args: []string{"--no-cache"}, want: false},
+		{name: "negation set to false", args: []string{"--no-cache=false"}, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cache bool
+			cmd := &Cmd{Use: "negatable-test"}
+			NegatableBoolVarP(cmd.Flags(), &cache, "cache", "", false, "use the cache")
+			if tt.env != "" {
+				t.Setenv("NEG_CACHE", tt.env)
+				cmd.SetAutomaticEnv("NEG")
+			}
+			if tt.config {
+				cmd.SetConfig(Config{Paths: []string{path}})
+			}
+			cmd.SetRun(func(c *Cmd, args []string) error { return nil })
+			cmd.SetOutputStream(&bytes.Buffer{})
+			cmd.SetErrorStream(&bytes.Buffer{})
+			cmd.SetArgs(tt.args)
+
+			if err := cmd.Execute(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if cache != tt.want {
+				t.Errorf("cache = %v, want %v", cache, tt.want)
+			}
+		})
+	}
+}
+
+func TestNegatableFlag_Help(t *testing.T) {
+	cmd := &Cmd{Use: "app"}
+	cmd.Flags().Bool("cache", true, "use the cache")
+	if err := cmd.MarkFlagNegatable("cache"); err != nil {
+		t.Fatal(err)
+	}
+
+	usage := cmd.LocalFlagUsages()
+	if !strings.Contains(usage, "--[no-]cache") {
+		t.Errorf("usage = %q, want the flag shown as --[no-]cache", usage)
+	}
+
+	if strings.Contains(usage, "--no-cache") {
+		t.Errorf("usage = %q, want the negation hidden", usage)
+	}
+}
+
+func TestMarkFlagNegatable_Errors(t *testing.T) {
+	cmd := &Cmd{Use: "app"}
+	cmd.Flags().String("name", "", "name")
+
+	if err := cmd.MarkFlagNegatable("missing"); err == nil {
+		t.Error("expected an error for a missing flag")
+	}
+
+	if err := cmd.MarkFlagNegatable("name"); err == nil {
+		t.Error("expected an error for a flag which is not a bool")
+	}
+}
@@ -0,0 +1,102 @@
+package cli
+
+import (
+	"fmt"
+	flag "github.com/rsb/pflag"
+	"strings"
+)
+
+// NormalizeDashes is a GlobalNormalizeFlagFn accepting underscores and dots
+// in place of dashes, --dry_run and --dry.run both match --dry-run.
+func NormalizeDashes(_ *flag.FlagSet, name string) flag.NormalizedName {
+	return flag.NormalizedName(strings.NewReplacer("_", "-", ".", "-").Replace(name))
+}
+
+// NormalizeCase is a GlobalNormalizeFlagFn matching flag names regardless of
+// their case, --Dry-Run matches --dry-run. Flags are shown in lower case.
+func NormalizeCase(_ *flag.FlagSet, name string) flag.NormalizedName {
+	return flag.NormalizedName(strings.ToLower(name))
+}
+
+// NormalizeRenames returns a GlobalNormalizeFlagFn mapping the old names of
+// renamed flags to their new names. It is silent, use SetFlagRenames to warn
+// when an old name is given in the args.
+func NormalizeRenames(renames map[string]string) GlobalNormalizeFlagFn {
+	return func(_ *flag.FlagSet, name string) flag.NormalizedName {
+		if renamed, ok := renames[name]; ok {
+			return flag.NormalizedName(renamed)
+		}
+
+		return flag.NormalizedName(name)
+	}
+}
+
+// SetFlagRenames maps the old names of renamed flags to their new names for
+// the whole command tree. Old names given in the args, matched after the
+// global normalization, are replaced before the flags are parsed and each one
+// raises a flag warning handled by the FlagWarningPolicy. Flags still defined
+// under an old name are left alone.
+func (c *Cmd) SetFlagRenames(renames map[string]string) {
+	c.Root().flagRenames = renames
+}
+
+// renameFlagArgs replaces the old names of renamed flags in args by their new
+// names and records a warning for each one used.
+func (c *Cmd) renameFlagArgs(args []string) []string {
+	renames := c.Root().flagRenames
+	if len(renames) == 0 {
+		return args
+	}
+
+	result := make([]string, 0, len(args))
+	for i, s := range args {
+		if s == "--" {
+			return append(result, args[i:]...)
+		}
+
+		if !strings.HasPrefix(s, "--") {
+			result = append(result, s)
+			continue
+		}
+
+		parts := strings.SplitN(s[2:], "=", 2)
+		name := parts[0]
+		if c.IsGlobalNormalizationEnabled() {
+			name = string(c.GlobalNormalization()(c.Flags(), name))
+		}
+
+		renamed, ok := renames[name]
+		if !ok || c.Flags().Lookup(name) != nil {
+			result = append(result, s)
+			continue
+		}
+
+		warning := fmt.Sprintf("Flag --%s has been renamed, use --%s instead", parts[0], renamed)
+		if !stringInSlice(warning, c.flagWarnings) {
+			c.flagWarnings = append(c.flagWarnings, warning)
+		}
+
+		parts[0] = renamed
+		result = append(result, "--"+strings.Join(parts, "="))
+	}
+
+	return result
+}
+
+// ChainNormalizers returns a GlobalNormalizeFlagFn applying fns in order,
+// each one normalizing the name returned by the previous one.
+//
+//	root.SetGlobalNormalization(cli.ChainNormalizers(
+//		cli.NormalizeDashes,
+//		cli.NormalizeCase,
+//		cli.NormalizeRenames(map[string]string{"dryrun": "dry-run"}),
+//	))
+func ChainNormalizers(fns ...GlobalNormalizeFlagFn) GlobalNormalizeFlagFn {
+	return func(f *flag.FlagSet, name string) flag.NormalizedName {
+		for _, fn := range fns {
+			name = string(fn(f, name))
+		}
+
+		return flag.NormalizedName(name)
+	}
+}
@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestSetFlagRenames(t *testing.T) {
+	var dryRun bool
+	var out string
+
+	root := &Cmd{Use: "app"}
+	child := &Cmd{Use: "deploy"}
+	child.Flags().BoolVar(&dryRun, "dry-run", false, "dry run")
+	child.Flags().StringVar(&out, "output", "", "output")
+	child.SetRun(func(c *Cmd, args []string) error { return nil })
+	root.Add(child)
+
+	root.SetGlobalNormalization(NormalizeCase)
+	child.SetFlagRenames(map[string]string{"dryrun": "dry-run", "out": "output"})
+
+	errs := &bytes.Buffer{}
+	root.SetOutputStream(&bytes.Buffer{})
+	root.SetErrorStream(errs)
+	root.SetArgs([]string{"deploy", "--DryRun", "--out=x", "--out", "y", "--", "--dryrun"})
+
+	if err := root.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !dryRun || out != "y" {
+		t.Errorf("dry-run = %v, output = %q, want true and y", dryRun, out)
+	}
+
+	want := []string{
+		"Flag --DryRun has been renamed, use --dry-run instead",
+		"Flag --out has been renamed, use --output instead",
+	}
+	if !reflect.DeepEqual(child.FlagWarnings(), want) {
+		t.Errorf("FlagWarnings() = %q, want %q", child.FlagWarnings(), want)
+	}
+
+	if got := errs.String(); got != want[0]+"\n"+want[1]+"\n" {
+		t.Errorf("printed warnings = %q", got)
+	}
+}
+
+func TestNormalizeRenames_Pure(t *testing.T) {
+	errs := &bytes.Buffer{}
+	cmd := &Cmd{Use: "app"}
+	cmd.Flags().Bool("dry-run", false, "dry run")
+	cmd.SetGlobalNormalization(NormalizeRenames(map[string]string{"dryrun": "dry-run"}))
+	cmd.Flags().SetOutput(errs)
+
+	if cmd.Flags().Lookup("dryrun") == nil {
+		t.Fatal("expected the old name to find the renamed flag")
+	}
+
+	if errs.Len() != 0 {
+		t.Errorf("normalizer wrote %q", errs.String())
+	}
+}
@@ -0,0 +1,403 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/rsb/failure"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ArgType determines how the value of a declared positional argument is
+// parsed before the lifecycle is fired.
+type ArgType int
+
+const (
+	// ArgString keeps the value as it was typed
+	ArgString ArgType = iota
+
+	// ArgInt parses the value with strconv.Atoi
+	ArgInt
+
+	// ArgDuration parses the value with time.ParseDuration
+	ArgDuration
+
+	// ArgPath cleans the value with filepath.Clean
+	ArgPath
+
+	// ArgEnum only accepts one of the values listed in Arg.Enum
+	ArgEnum
+)
+
+func (t ArgType) String() string {
+	switch t {
+	case ArgInt:
+		return "int"
+	case ArgDuration:
+		return "duration"
+	case ArgPath:
+		return "path"
+	case ArgEnum:
+		return "enum"
+	default:
+		return "string"
+	}
+}
+
+// Arg declares a named positional argument of a command. Declared args are
+// validated and parsed after the flags and before GlobalPreRun, and are
+// used to build the usage line and the Arguments section of help.
+//
+// Name:			used in the usage line and to look up the value
+// Description:	shown in help
+// Type:			how the value is parsed
+// Enum:			the allowed values when Type is ArgEnum
+// Optional:		the arg may be omitted, all args after it must be optional
+// Variadic:		the arg accepts one or more values, it must be the last arg
+// Default:		the value parsed when an optional arg is omitted
+type Arg struct {
+	Name        string
+	Description string
+	Type        ArgType
+	Enum        []string
+	Optional    bool
+	Variadic    bool
+	Default     string
+}
+
+// UseLine returns the arg written with the Use grammar
+func (a Arg) UseLine() string {
+	line := a.Name
+	if a.Variadic {
+		line += "..."
+	}
+
+	if a.Optional {
+		line = "[" + line + "]"
+	}
+
+	return line
+}
+
+func (a Arg) parse(s string) (interface{}, error) {
+	switch a.Type {
+	case ArgInt:
+		return strconv.Atoi(s)
+	case ArgDuration:
+		return time.ParseDuration(s)
+	case ArgPath:
+		return filepath.Clean(s), nil
+	case ArgEnum:
+		if !stringInSlice(s, a.Enum) {
+			return nil, failure.InvalidParam("must be one of %s", strings.Join(a.Enum, "|"))
+		}
+		return s, nil
+	default:
+		return s, nil
+	}
+}
+
+// DeclareArgs assigns the positional arguments of the command, in order.
+// NOTE: this will panic if the declarations can not be satisfied, for example
+// a required arg after an optional one or a default its type rejects.
+func (c *Cmd) DeclareArgs(args ...Arg) {
+	for i, a := range args {
+		if a.Name == "" {
+			panic(fmt.Sprintf("[DeclareArgs Failed] arg at position %d has no name", i))
+		}
+
+		if a.Variadic && i != len(args)-1 {
+			panic(fmt.Sprintf("[DeclareArgs Failed] variadic arg (%s) must be the last arg", a.Name))
+		}
+
+		if !a.Optional && i > 0 && args[i-1].Optional {
+			panic(fmt.Sprintf("[DeclareArgs Failed] required arg (%s) can not follow an optional arg", a.Name))
+		}
+
+		if a.Type == ArgEnum && len(a.Enum) == 0 {
+			panic(fmt.Sprintf("[DeclareArgs Failed] enum arg (%s) has no values", a.Name))
+		}
+
+		if a.Default != "" {
+			if _, err := a.parse(a.Default); err != nil {
+				panic(fmt.Sprintf("[DeclareArgs Failed] default %q of arg (%s) is invalid: %v", a.Default, a.Name, err))
+			}
+		}
+	}
+
+	c.positional.Declared = args
+}
+
+// DeclaredArgs returns the positional arguments declared on the command
+func (c *Cmd) DeclaredArgs() []Arg {
+	return c.positional.Declared
+}
+
+// HasDeclaredArgs determines if the command declared positional arguments
+func (c *Cmd) HasDeclaredArgs() bool {
+	return len(c.positional.Declared) > 0
+}
+
+// BindArgs assigns a pointer to a struct which is populated with the parsed
+// args before GlobalPreRun. Fields are matched with the `arg` tag:
+//
+//	type opts struct {
+//		Name    string        `arg:"name"`
+//		Timeout time.Duration `arg:"timeout"`
+//		Files   []string      `arg:"files"`
+//	}
+func (c *Cmd) BindArgs(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return failure.InvalidParam("BindArgs expects a pointer to a struct, got (%T)", v)
+	}
+
+	c.positional.Target = v
+	return nil
+}
+
+// ArgsUseLine returns the declared args written with the Use grammar
+func (c *Cmd) ArgsUseLine() string {
+	var parts []string
+	for _, a := range c.positional.Declared {
+		parts = append(parts, a.UseLine())
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// ArgUsages returns the help text for the declared args
+func (c *Cmd) ArgUsages() string {
+	maxLen := 0
+	for _, a := range c.positional.Declared {
+		if len(a.Name) > maxLen {
+			maxLen = len(a.Name)
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	for _, a := range c.positional.Declared {
+		line := "  " + rpad(a.Name, maxLen) + "   " + a.Description
+		if a.Type == ArgEnum {
+			line += fmt.Sprintf(" (one of: %s)", strings.Join(a.Enum, "|"))
+		} else if a.Type != ArgString {
+			line += fmt.Sprintf(" (%s)", a.Type)
+		}
+
+		if a.Default != "" {
+			line += fmt.Sprintf(" (default %q)", a.Default)
+		}
+
+		_, _ = fmt.Fprintln(buf, line)
+	}
+
+	return buf.String()
+}
+
+// ArgString returns the string value of a declared string, path or enum arg
+func (c *Cmd) ArgString(name string) (string, error) {
+	values, err := c.argValues(name, ArgString, ArgPath, ArgEnum)
+	if err != nil || len(values) == 0 {
+		return "", err
+	}
+
+	return values[0].(string), nil
+}
+
+// ArgStrings returns all the values of a declared string, path or enum arg
+func (c *Cmd) ArgStrings(name string) ([]string, error) {
+	values, err := c.argValues(name, ArgString, ArgPath, ArgEnum)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		result = append(result, v.(string))
+	}
+
+	return result, nil
+}
+
+// ArgInt returns the int value of a declared int arg
+func (c *Cmd) ArgInt(name string) (int, error) {
+	values, err := c.argValues(name, ArgInt)
+	if err != nil || len(values) == 0 {
+		return 0, err
+	}
+
+	return values[0].(int), nil
+}
+
+// ArgInts returns all the values of a declared int arg
+func (c *Cmd) ArgInts(name string) ([]int, error) {
+	values, err := c.argValues(name, ArgInt)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]int, 0, len(values))
+	for _, v := range values {
+		result = append(result, v.(int))
+	}
+
+	return result, nil
+}
+
+// ArgDuration returns the time.Duration value of a declared duration arg
+func (c *Cmd) ArgDuration(name string) (time.Duration, error) {
+	values, err := c.argValues(name, ArgDuration)
+	if err != nil || len(values) == 0 {
+		return 0, err
+	}
+
+	return values[0].(time.Duration), nil
+}
+
+// ArgDurations returns all the values of a declared duration arg
+func (c *Cmd) ArgDurations(name string) ([]time.Duration, error) {
+	values, err := c.argValues(name, ArgDuration)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]time.Duration, 0, len(values))
+	for _, v := range values {
+		result = append(result, v.(time.Duration))
+	}
+
+	return result, nil
+}
+
+func (c *Cmd) argValues(name string, types ...ArgType) ([]interface{}, error) {
+	for _, a := range c.positional.Declared {
+		if a.Name != name {
+			continue
+		}
+
+		for _, t := range types {
+			if a.Type == t {
+				return c.positional.Values[name], nil
+			}
+		}
+
+		return nil, failure.InvalidState("trying to get %s value of arg (%s) of type %s", types[0], name, a.Type)
+	}
+
+	return nil, failure.NotFound("arg (%s), is not declared", name)
+}
+
+// parseDeclaredArgs checks the number of args against the declarations,
+// parses each value and populates the struct given to BindArgs.
+func (c *Cmd) parseDeclaredArgs(args []string) error {
+	declared := c.positional.Declared
+	if len(declared) == 0 {
+		return nil
+	}
+
+	min, max := 0, len(declared)
+	for _, a := range declared {
+		if !a.Optional {
+			min++
+		}
+		if a.Variadic {
+			max = -1
+		}
+	}
+
+	if len(args) < min {
+		missing := declared[len(args)].Name
+		return newArgsError(c, args, "missing required arg (%s)", missing)
+	}
+
+	if max >= 0 && len(args) > max {
+		return newArgsError(c, args, "accepts at most %d arg(s), received %d", max, len(args))
+	}
+
+	values := map[string][]interface{}{}
+	for i, a := range declared {
+		var raw []string
+		switch {
+		case i >= len(args):
+			if a.Default != "" {
+				raw = []string{a.Default}
+			}
+		case a.Variadic:
+			raw = args[i:]
+		default:
+			raw = []string{args[i]}
+		}
+
+		for _, s := range raw {
+			v, err := a.parse(s)
+			if err != nil {
+				return newArgsError(c, args, "invalid value %q for arg (%s): %v", s, a.Name, err)
+			}
+			values[a.Name] = append(values[a.Name], v)
+		}
+	}
+	c.positional.Values = values
+
+	if c.positional.Target == nil {
+		return nil
+	}
+
+	return c.bindDeclaredArgs()
+}
+
+func (c *Cmd) bindDeclaredArgs() error {
+	rv := reflect.ValueOf(c.positional.Target).Elem()
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		name, ok := rt.Field(i).Tag.Lookup("arg")
+		if !ok {
+			continue
+		}
+
+		values, ok := c.positional.Values[name]
+		if !ok || len(values) == 0 {
+			continue
+		}
+
+		field := rv.Field(i)
+		if field.Kind() != reflect.Slice {
+			if err := assignArgValue(field, values[0]); err != nil {
+				return failure.Wrap(err, "BindArgs field (%s)", rt.Field(i).Name)
+			}
+			continue
+		}
+
+		slice := reflect.MakeSlice(field.Type(), len(values), len(values))
+		for j, v := range values {
+			if err := assignArgValue(slice.Index(j), v); err != nil {
+				return failure.Wrap(err, "BindArgs field (%s)", rt.Field(i).Name)
+			}
+		}
+		field.Set(slice)
+	}
+
+	return nil
+}
+
+func assignArgValue(field reflect.Value, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != field.Kind() || !rv.Type().ConvertibleTo(field.Type()) {
+		return failure.InvalidParam("can not assign %s to %s", rv.Type(), field.Type())
+	}
+
+	field.Set(rv.Convert(field.Type()))
+	return nil
+}
+
+// Positional holds the declared positional arguments of a command
+// Declared:	the args in the order they are expected
+// Values:		the parsed values keyed by arg name
+// Target:		the struct populated by BindArgs
+type Positional struct {
+	Declared []Arg
+	Values   map[string][]interface{}
+	Target   interface{}
+}
@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDeclareArgs_Panics(t *testing.T) {
+	tests := []struct {
+		name string
+		args []Arg
+		want string
+	}{
+		{
+			name: "no name",
+			args: []Arg{{}},
+			want: "has no name",
+		},
+		{
+			name: "variadic not last",
+			args: []Arg{{Name: "files", Variadic: true}, {Name: "dst"}},
+			want: "must be the last arg",
+		},
+		{
+			name: "required after optional",
+			args: []Arg{{Name: "a", Optional: true}, {Name: "b"}},
+			want: "can not follow an optional arg",
+		},
+		{
+			name: "enum without values",
+			args: []Arg{{Name: "mode", Type: ArgEnum}},
+			want: "has no values",
+		},
+		{
+			name: "enum default not allowed",
+			args: []Arg{{Name: "mode", Type: ArgEnum, Enum: []string{"fast", "slow"}, Optional: true, Default: "medium"}},
+			want: `default "medium" of arg (mode) is invalid`,
+		},
+		{
+			name: "int default",
+			args: []Arg{{Name: "count", Type: ArgInt, Optional: true, Default: "ten"}},
+			want: `default "ten" of arg (count) is invalid`,
+		},
+		{
+			name: "duration default",
+			args: []Arg{{Name: "timeout", Type: ArgDuration, Optional: true, Default: "soon"}},
+			want: `default "soon" of arg (timeout) is invalid`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				r := recover()
+				if r == nil {
+					t.Fatal("expected DeclareArgs to panic")
+				}
+
+				if msg := fmt.Sprint(r); !strings.Contains(msg, tt.want) {
+					t.Errorf("panic = %q, want it to contain %q", msg, tt.want)
+				}
+			}()
+
+			(&Cmd{Use: "app"}).DeclareArgs(tt.args...)
+		})
+	}
+}
+
+func TestDeclareArgs_ValidDefaults(t *testing.T) {
+	cmd := &Cmd{Use: "app"}
+	cmd.DeclareArgs(
+		Arg{Name: "mode", Type: ArgEnum, Enum: []string{"fast", "slow"}},
+		Arg{Name: "count", Type: ArgInt, Optional: true, Default: "10"},
+		Arg{Name: "timeout", Type: ArgDuration, Optional: true, Default: "1m"},
+	)
+
+	if len(cmd.DeclaredArgs()) != 3 {
+		t.Errorf("DeclaredArgs() = %v", cmd.DeclaredArgs())
+	}
+}
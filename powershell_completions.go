@@ -0,0 +1,140 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+)
+
+// GenPowerShellCompletion generates the powershell completion script for the
+// root command and writes it to w. When includeDesc is false the completions
+// are shown without their descriptions.
+func (c *Cmd) GenPowerShellCompletion(w io.Writer, includeDesc bool) error {
+	name := c.Root().Name()
+	compCmd := ShellCompRequestCmd
+	if !includeDesc {
+		compCmd = ShellCompNoDescRequestCmd
+	}
+
+	_, err := fmt.Fprintf(w, powerShellCompletionTemplate, name, compCmd,
+		ShellCompDirectiveError, ShellCompDirectiveNoSpace, ShellCompDirectiveNoFileComp,
+		ShellCompDirectiveFilterFileExt, ShellCompDirectiveFilterDirs)
+
+	return err
+}
+
+const powerShellCompletionTemplate = `# powershell completion for %-36[1]s -*- shell-script -*-
+
+function __%[1]s_debug {
+    if ($env:BASH_COMP_DEBUG_FILE) {
+        "$args" | Out-File -Append -FilePath "$env:BASH_COMP_DEBUG_FILE"
+    }
+}
+
+function __%[1]s_result([string]$Text, [string]$List, [string]$Type, [string]$Tooltip) {
+    if (-Not $Tooltip) { $Tooltip = " " }
+    [System.Management.Automation.CompletionResult]::new($Text, $List, $Type, $Tooltip)
+}
+
+Register-ArgumentCompleter -Native -CommandName '%[1]s' -ScriptBlock {
+    param(
+        $WordToComplete,
+        $CommandAst,
+        $CursorPosition
+    )
+
+    $ShellCompDirectiveError=%[3]d
+    $ShellCompDirectiveNoSpace=%[4]d
+    $ShellCompDirectiveNoFileComp=%[5]d
+    $ShellCompDirectiveFilterFileExt=%[6]d
+    $ShellCompDirectiveFilterDirs=%[7]d
+
+    # Completion is triggered from the cursor, the text after it is ignored
+    $Command = "$($CommandAst.CommandElements)"
+    if ($Command.Length -gt $CursorPosition) {
+        $Command = $Command.Substring(0, $CursorPosition)
+    }
+
+    $Program, $Arguments = $Command.Split(" ", 2)
+    $RequestComp = "$Program %[2]s $Arguments"
+
+    # $WordToComplete is wrong when the cursor was moved, use the last arg
+    if ($WordToComplete -ne "") {
+        $WordToComplete = $Arguments.Split(" ")[-1]
+    }
+
+    # When completing a flag with an = the completions must be prefixed
+    # with the flag
+    $Prefix = ""
+    if ($WordToComplete -Like "-*=*") {
+        $Flag, $WordToComplete = $WordToComplete.Split("=", 2)
+        $Prefix = "$Flag="
+    } elseif ($WordToComplete -eq "") {
+        # The last word is complete, ask for the completions of a new one
+        $RequestComp = "$RequestComp" + ' ""'
+    }
+
+    __%[1]s_debug "Calling $RequestComp"
+    $Out = @(Invoke-Expression "$RequestComp" 2>$null)
+
+    # The directive is the integer following the colon on the last line
+    $Directive = 0
+    if ($Out.Count -gt 0 -and $Out[-1] -Like ":*") {
+        [int]$Directive = $Out[-1].TrimStart(':')
+        $Out = @($Out | Select-Object -SkipLast 1)
+    }
+    __%[1]s_debug "The completion directive is: $Directive"
+
+    if (($Directive -band $ShellCompDirectiveError) -ne 0) {
+        return
+    }
+
+    $Parent = Split-Path -Parent "$WordToComplete"
+
+    if (($Directive -band $ShellCompDirectiveFilterFileExt) -ne 0) {
+        # File extension filtering, directories are kept to allow descending
+        $Exts = $Out | ForEach-Object { ".$($_.Split("` + "`" + `t")[0])" }
+        Get-ChildItem -Path "$WordToComplete*" -ErrorAction SilentlyContinue | Where-Object {
+            $_.PSIsContainer -or ($Exts -contains $_.Extension)
+        } | ForEach-Object {
+            $Path = if ($Parent) { Join-Path $Parent $_.Name } else { $_.Name }
+            __%[1]s_result "$Prefix$Path" "$Path" 'ProviderItem' "$Path"
+        }
+        return
+    }
+
+    if (($Directive -band $ShellCompDirectiveFilterDirs) -ne 0) {
+        # File completion for directories only
+        $Base = "$WordToComplete"
+        if ($Out.Count -gt 0 -and $Out[0]) {
+            $Base = Join-Path $Out[0] "$WordToComplete"
+        }
+        Get-ChildItem -Path "$Base*" -Directory -ErrorAction SilentlyContinue | ForEach-Object {
+            $Path = if ($Parent) { Join-Path $Parent $_.Name } else { $_.Name }
+            __%[1]s_result "$Prefix$Path" "$Path" 'ProviderContainer' "$Path"
+        }
+        return
+    }
+
+    $Values = @($Out | ForEach-Object {
+        $Name, $Description = $_.Split("` + "`" + `t", 2)
+        @{Name = "$Name"; Description = "$Description"}
+    } | Where-Object { $_.Name -Like "$WordToComplete*" })
+
+    if ($Values.Count -eq 0) {
+        if (($Directive -band $ShellCompDirectiveNoFileComp) -ne 0) {
+            # An empty completion prevents powershell from completing files
+            ""
+        }
+        return
+    }
+
+    $NoSpace = ($Directive -band $ShellCompDirectiveNoSpace) -ne 0
+    $Values | ForEach-Object {
+        $Text = "$Prefix$($_.Name)"
+        if (-Not $NoSpace -and $Values.Count -eq 1) {
+            $Text = "$Text "
+        }
+        __%[1]s_result $Text "$($_.Name)" 'ParameterValue' "$($_.Description)"
+    }
+}
+`
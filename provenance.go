@@ -0,0 +1,160 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	flag "github.com/rsb/pflag"
+)
+
+const explainFlagName = "explain-flags"
+
+// FlagSource identifies where the value of a flag came from
+type FlagSource int
+
+const (
+	// FlagSourceDefault is the default value of the flag
+	FlagSourceDefault FlagSource = iota
+
+	// FlagSourceCommandLine was given in the args
+	FlagSourceCommandLine
+
+	// FlagSourceEnv was read from an environment variable
+	FlagSourceEnv
+
+	// FlagSourceConfig was read from a config file
+	FlagSourceConfig
+)
+
+func (s FlagSource) String() string {
+	switch s {
+	case FlagSourceCommandLine:
+		return "command line"
+	case FlagSourceEnv:
+		return "env"
+	case FlagSourceConfig:
+		return "config"
+	default:
+		return "default"
+	}
+}
+
+// FlagOrigin records where the value of a flag came from.
+// Source:	the kind of source
+// Detail:	the environment variable or config file and key it was read from
+type FlagOrigin struct {
+	Source FlagSource
+	Detail string
+}
+
+func (o FlagOrigin) String() string {
+	if o.Detail == "" {
+		return o.Source.String()
+	}
+
+	return o.Source.String() + " " + o.Detail
+}
+
+// FlagOrigin returns where the value of the named flag came from during the
+// last execution of the command.
+func (c *Cmd) FlagOrigin(name string) FlagOrigin {
+	return c.origins[name]
+}
+
+// FlagOrigins returns where the value of every flag which was not left to
+// its default came from during the last execution of the command.
+func (c *Cmd) FlagOrigins() map[string]FlagOrigin {
+	origins := make(map[string]FlagOrigin, len(c.origins))
+	for name, o := range c.origins {
+		origins[name] = o
+	}
+
+	return origins
+}
+
+// EnableExplainFlags adds the hidden global --explain-flags flag to the root
+// command when the command tree executes. When set, the effective value and
+// origin of every flag is printed instead of running the command, or showing
+// the help of a command which is not runnable.
+func (c *Cmd) EnableExplainFlags() {
+	c.Root().explainFlags = true
+}
+
+// InitDefaultExplainFlag adds the hidden global --explain-flags flag to the
+// root command when enabled by EnableExplainFlags. It is left out when the
+// root already defines a flag with that name.
+func (c *Cmd) InitDefaultExplainFlag() {
+	root := c.Root()
+	if !root.explainFlags || root.GlobalFlags().Lookup(explainFlagName) != nil || root.Flags().Lookup(explainFlagName) != nil {
+		return
+	}
+
+	root.GlobalFlags().Bool(explainFlagName, false, "print the value of every flag and where it came from")
+	_ = root.GlobalFlags().MarkHidden(explainFlagName)
+}
+
+// isExplainingFlags determines if the --explain-flags flag of the root was
+// set, a flag of the same name defined by the command itself does not count.
+func (c *Cmd) isExplainingFlags() bool {
+	f := c.Flags().Lookup(explainFlagName)
+	if f == nil || !c.Root().explainFlags || f != c.Root().GlobalFlags().Lookup(explainFlagName) {
+		return false
+	}
+
+	return f.Value.String() == "true"
+}
+
+// ExplainFlags returns a table of the effective value of every flag and
+// where it came from.
+func (c *Cmd) ExplainFlags() string {
+	type row struct{ name, value, origin string }
+	rows := []row{{"FLAG", "VALUE", "SOURCE"}}
+	nameLen, valueLen := 0, 0
+
+	c.Flags().VisitAll(func(f *flag.Flag) {
+		if f.Name == "help" || f.Name == explainFlagName {
+			return
+		}
+
+		if _, negates := negatedFlag(f); negates {
+			return
+		}
+
+		r := row{name: "--" + f.Name, value: redactFlagValue(f, f.Value.String()), origin: c.FlagOrigin(f.Name).String()}
+		rows = append(rows, r)
+	})
+
+	for _, r := range rows {
+		if len(r.name) > nameLen {
+			nameLen = len(r.name)
+		}
+		if len(r.value) > valueLen {
+			valueLen = len(r.value)
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	for _, r := range rows {
+		_, _ = fmt.Fprintf(buf, "%s   %s   %s\n", rpad(r.name, nameLen), rpad(r.value, valueLen), r.origin)
+	}
+
+	return buf.String()
+}
+
+// resetFlagOrigins records the flags set in the args as coming from the
+// command line, every other flag starts from its default.
+func (c *Cmd) resetFlagOrigins() {
+	c.origins = map[string]FlagOrigin{}
+	c.Flags().VisitAll(func(f *flag.Flag) {
+		if f.Changed {
+			c.origins[f.Name] = FlagOrigin{Source: FlagSourceCommandLine}
+		}
+	})
+}
+
+func (c *Cmd) setFlagOrigin(name string, source FlagSource, detail string) {
+	if c.origins == nil {
+		c.origins = map[string]FlagOrigin{}
+	}
+
+	c.origins[name] = FlagOrigin{Source: source, Detail: detail}
+}
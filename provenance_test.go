@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestExplainFlags_OptIn(t *testing.T) {
+	root := &Cmd{Use: "app"}
+	root.Flags().String("name", "x", "name")
+	root.SetRun(func(c *Cmd, args []string) error { return nil })
+	root.SetOutputStream(&bytes.Buffer{})
+	root.SetErrorStream(&bytes.Buffer{})
+	root.SetArgs([]string{"--explain-flags"})
+
+	if err := root.Execute(); err == nil {
+		t.Error("expected --explain-flags to be unknown until EnableExplainFlags is called")
+	}
+}
+
+func TestExplainFlags_NotRunnable(t *testing.T) {
+	root := &Cmd{Use: "app"}
+	root.GlobalFlags().String("name", "x", "name")
+	sub := &Cmd{Use: "sub"}
+	sub.SetRun(func(c *Cmd, args []string) error { return nil })
+	root.Add(sub)
+	root.EnableExplainFlags()
+
+	out := &bytes.Buffer{}
+	root.SetOutputStream(out)
+	root.SetErrorStream(&bytes.Buffer{})
+	root.SetArgs([]string{"--explain-flags", "--name", "y"})
+
+	if err := root.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !strings.Contains(out.String(), "--name") || strings.Contains(out.String(), "Usage:") {
+		t.Errorf("expected the flags to be explained instead of the help, got %q", out.String())
+	}
+}
+
+func TestExplainFlags_NameTaken(t *testing.T) {
+	var ran bool
+	root := &Cmd{Use: "app"}
+	sub := &Cmd{Use: "sub"}
+	sub.Flags().Bool(explainFlagName, false, "the command's own flag")
+	sub.SetRun(func(c *Cmd, args []string) error {
+		ran = true
+		return nil
+	})
+	root.Add(sub)
+	root.EnableExplainFlags()
+
+	root.SetOutputStream(&bytes.Buffer{})
+	root.SetErrorStream(&bytes.Buffer{})
+	root.SetArgs([]string{"sub", "--explain-flags"})
+
+	if err := root.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !ran {
+		t.Error("expected the command to run with its own --explain-flags")
+	}
+}
@@ -0,0 +1,180 @@
+package cli
+
+import (
+	"fmt"
+	"github.com/rsb/failure"
+	flag "github.com/rsb/pflag"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	// FlagAnnotationSecret marks a flag whose value is a secret
+	FlagAnnotationSecret = "cli_annotation_flag_secret"
+
+	// redactedValue replaces the value of a secret flag in any output
+	redactedValue = "********"
+)
+
+// MarkFlagSecret marks the named flag as a secret, see MarkFlagSecret
+func (c *Cmd) MarkFlagSecret(name string) error {
+	return MarkFlagSecret(c.Flags(), name)
+}
+
+// MarkGlobalFlagSecret marks the named global flag as a secret, see
+// MarkFlagSecret
+func (c *Cmd) MarkGlobalFlagSecret(name string) error {
+	return MarkFlagSecret(c.GlobalFlags(), name)
+}
+
+// MarkFlagSecret marks the named flag as a secret, like a token. Its default
+// is never shown in help and its value is redacted from ExplainFlags and
+// errors. Two flags are added next to it so the secret stays out of the
+// args: --<name>-stdin reads the value from the input stream and
+// --<name>-file reads it from a file, environment variables work as for any
+// flag. A warning is raised when the secret itself is given in the args.
+func MarkFlagSecret(flags *flag.FlagSet, name string) error {
+	f := flags.Lookup(name)
+	if f == nil {
+		return failure.NotFound("flag (%s)", name)
+	}
+
+	if err := flags.SetAnnotation(name, FlagAnnotationSecret, []string{"true"}); err != nil {
+		return err
+	}
+	f.Default = ""
+
+	if flags.Lookup(name+"-stdin") == nil {
+		flags.Bool(name+"-stdin", false, fmt.Sprintf("read --%s from stdin", name))
+	}
+
+	if flags.Lookup(name+"-file") == nil {
+		flags.String(name+"-file", "", fmt.Sprintf("read --%s from a file", name))
+		if err := MarkFlagFilename(flags, name+"-file"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func isFlagSecret(f *flag.Flag) bool {
+	secret, found := f.Annotations[FlagAnnotationSecret]
+	return found && len(secret) > 0 && secret[0] == "true"
+}
+
+// redactFlagValue returns value unless f is a secret
+func redactFlagValue(f *flag.Flag, value string) string {
+	if f == nil || !isFlagSecret(f) || value == "" {
+		return value
+	}
+
+	return redactedValue
+}
+
+// redactFlagError returns err unless f is a secret. The errors raised while
+// setting a flag quote the value they were given, for a secret err is
+// replaced by one which leaves the value out.
+func redactFlagError(f *flag.Flag, err error) error {
+	if err == nil || f == nil || !isFlagSecret(f) {
+		return err
+	}
+
+	return failure.InvalidParam("invalid argument %q for %q flag", redactedValue, "--"+f.Name)
+}
+
+// applySecretFlags reads the secret flags given with their -stdin or -file
+// flags and warns about the secrets given in the args.
+func (c *Cmd) applySecretFlags() error {
+	flags := c.Flags()
+	var secrets, stdin []string
+	flags.VisitAll(func(f *flag.Flag) {
+		if !isFlagSecret(f) {
+			return
+		}
+
+		secrets = append(secrets, f.Name)
+		if read, _ := flags.GetBool(f.Name + "-stdin"); read {
+			stdin = append(stdin, f.Name+"-stdin")
+		}
+	})
+
+	if len(stdin) > 1 {
+		return &FlagGroupError{
+			Path:   c.Path(),
+			Kind:   FlagGroupMutuallyExclusive,
+			Group:  stdin,
+			Reason: fmt.Sprintf("only one of the flags %v can read stdin", stdin),
+		}
+	}
+
+	for _, name := range secrets {
+		if err := c.applySecretFlag(name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// applySecretFlag sets the named secret from stdin or a file. It runs after
+// the environment and config so their -stdin and -file flags are honored, a
+// secret given in the args still wins over them.
+func (c *Cmd) applySecretFlag(name string) error {
+	flags := c.Flags()
+	readStdin, _ := flags.GetBool(name + "-stdin")
+	group := []string{name, name + "-stdin", name + "-file"}
+	var set []string
+	for _, n := range group {
+		if c.FlagOrigin(n).Source == FlagSourceCommandLine && (n != name+"-stdin" || readStdin) {
+			set = append(set, n)
+		}
+	}
+
+	if len(set) > 1 {
+		return &FlagGroupError{
+			Path:   c.Path(),
+			Kind:   FlagGroupMutuallyExclusive,
+			Group:  group,
+			Reason: fmt.Sprintf("if any flags in the group %v are set none of the others can be; %v were all set", group, set),
+		}
+	}
+
+	var value, source, from string
+	var err error
+	switch {
+	case c.FlagOrigin(name).Source == FlagSourceCommandLine:
+		c.flagWarnings = append(c.flagWarnings, fmt.Sprintf(
+			"Flag --%s is a secret given in the args, use --%s-stdin, --%s-file or the environment instead",
+			name, name, name))
+		return nil
+	case readStdin:
+		from = name + "-stdin"
+		source = "--" + from
+		var data []byte
+		data, err = io.ReadAll(c.InputStream())
+		value = string(data)
+	case flags.Changed(name + "-file"):
+		from = name + "-file"
+		path, _ := flags.GetString(from)
+		source = "--" + from + " " + path
+		var data []byte
+		data, err = os.ReadFile(path)
+		value = string(data)
+	default:
+		return nil
+	}
+
+	if err != nil {
+		return failure.Wrap(err, "reading secret flag (%s) from %s", name, source)
+	}
+
+	value = strings.TrimRight(value, "\r\n")
+	if err := flags.Set(name, value); err != nil {
+		return &FlagSourceError{Path: c.Path(), Flag: name, Source: source, Value: redactedValue, Err: redactFlagError(flags.Lookup(name), err)}
+	}
+
+	c.setFlagOrigin(name, c.FlagOrigin(from).Source, source)
+	return nil
+}
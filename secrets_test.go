@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSecretFlag_ParseErrorRedacted(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		env  string
+	}{
+		{name: "command line", args: []string{"--pin", "hunter2"}},
+		{name: "command line with equals", args: []string{"--pin=hunter2"}},
+		{name: "environment", args: []string{}, env: "hunter2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var pin int
+			cmd := &Cmd{Use: "app"}
+			cmd.Flags().IntVar(&pin, "pin", 0, "pin")
+			if err := cmd.MarkFlagSecret("pin"); err != nil {
+				t.Fatal(err)
+			}
+			if tt.env != "" {
+				t.Setenv("APP_PIN", tt.env)
+				cmd.SetAutomaticEnv("APP")
+			}
+			cmd.SetRun(func(c *Cmd, args []string) error { return nil })
+			cmd.SetOutputStream(&bytes.Buffer{})
+			errs := &bytes.Buffer{}
+			cmd.SetErrorStream(errs)
+			cmd.SetArgs(tt.args)
+
+			err := cmd.Execute()
+			if err == nil {
+				t.Fatal("expected an invalid value error")
+			}
+
+			if strings.Contains(err.Error(), "hunter2") || strings.Contains(errs.String(), "hunter2") {
+				t.Errorf("secret leaked in %q, printed %q", err.Error(), errs.String())
+			}
+
+			if !strings.Contains(err.Error(), redactedValue) {
+				t.Errorf("error = %q, want the redacted value", err.Error())
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strings"
 )
 
 // Streams represents the 3 modes by which data travels via the cli.
@@ -12,11 +13,13 @@ import (
 // Err: the standard error os.Stderr of the app.
 //
 // These can all be controlled by the user, but left on touched the defaults
-// are listed as above
+// are listed as above. Debugf, Infof, Warnf and Errorf log to Err when their
+// level is at least the LogLevel of the streams.
 type Streams struct {
-	in  io.Reader
-	out io.Writer
-	err io.Writer
+	in    io.Reader
+	out   io.Writer
+	err   io.Writer
+	level LogLevel
 }
 
 // NewStreams constructor used to create in/out and err streams
@@ -91,3 +94,88 @@ func (ds *Streams) PrintErrln(i ...interface{}) {
 func (ds *Streams) PrintErrf(i ...interface{}) {
 	ds.Print(fmt.Sprintln(i...))
 }
+
+// SetLogLevel assigns the lowest level of the messages logged to Err
+func (ds *Streams) SetLogLevel(l LogLevel) {
+	ds.level = l
+}
+
+// LogLevel returns the lowest level of the messages logged to Err
+func (ds *Streams) LogLevel() LogLevel {
+	return ds.level
+}
+
+// Logf writes the message to Err when its level is enabled, prefixed with
+// the level unless it is LogInfo. A newline is added when missing.
+func (ds *Streams) Logf(l LogLevel, format string, i ...interface{}) {
+	if l < ds.level || l >= LogSilent {
+		return
+	}
+
+	msg := fmt.Sprintf(format, i...)
+	if l != LogInfo {
+		msg = l.String() + ": " + msg
+	}
+
+	if !strings.HasSuffix(msg, "\n") {
+		msg += "\n"
+	}
+
+	ds.PrintErr(msg)
+}
+
+// Debugf logs a LogDebug message, see Logf
+func (ds *Streams) Debugf(format string, i ...interface{}) {
+	ds.Logf(LogDebug, format, i...)
+}
+
+// Infof logs a LogInfo message, see Logf
+func (ds *Streams) Infof(format string, i ...interface{}) {
+	ds.Logf(LogInfo, format, i...)
+}
+
+// Warnf logs a LogWarn message, see Logf
+func (ds *Streams) Warnf(format string, i ...interface{}) {
+	ds.Logf(LogWarn, format, i...)
+}
+
+// Errorf logs a LogError message, see Logf
+func (ds *Streams) Errorf(format string, i ...interface{}) {
+	ds.Logf(LogError, format, i...)
+}
+
+// LogLevel is the level of the messages logged by Streams. The zero value
+// is LogWarn, each --verbose lowers the level and each --quiet raises it.
+type LogLevel int
+
+const (
+	// LogDebug logs every message, it is reached with -vv
+	LogDebug LogLevel = iota - 2
+
+	// LogInfo logs informational messages and above, it is reached with -v
+	LogInfo
+
+	// LogWarn logs warnings and errors, it is the default level
+	LogWarn
+
+	// LogError only logs errors, it is reached with -q
+	LogError
+
+	// LogSilent logs nothing, it is reached with -qq
+	LogSilent
+)
+
+func (l LogLevel) String() string {
+	switch {
+	case l <= LogDebug:
+		return "debug"
+	case l == LogInfo:
+		return "info"
+	case l == LogWarn:
+		return "warning"
+	case l == LogError:
+		return "error"
+	default:
+		return "silent"
+	}
+}
@@ -0,0 +1,215 @@
+package cli
+
+import (
+	"fmt"
+	"github.com/rsb/failure"
+	flag "github.com/rsb/pflag"
+	"sort"
+	"strings"
+)
+
+// defaultSuggestionsMinimumDistance is used when SuggestionsMinimumDistance
+// is not set on the command.
+const defaultSuggestionsMinimumDistance = 2
+
+// UnknownCommandError is returned when the args name a subcommand which does
+// not exist. Suggestions holds the names of the closest matching commands.
+type UnknownCommandError struct {
+	Path        string
+	Name        string
+	Suggestions []string
+}
+
+func (e *UnknownCommandError) Error() string {
+	msg := fmt.Sprintf("unknown command %q for %q", e.Name, e.Path)
+	if len(e.Suggestions) == 0 {
+		return msg
+	}
+
+	return msg + didYouMean(e.Suggestions)
+}
+
+// Unwrap allows failure.IsNotFound to identify the error
+func (e *UnknownCommandError) Unwrap() error {
+	return failure.NotFound("command (%s)", e.Name)
+}
+
+// UnknownFlagError wraps the pflag error for a flag which is not defined on
+// the command. Suggestions holds the closest flags, written as they would be
+// typed on the command line.
+type UnknownFlagError struct {
+	Name        string
+	Suggestions []string
+	err         error
+}
+
+func (e *UnknownFlagError) Error() string {
+	return e.err.Error() + didYouMean(e.Suggestions)
+}
+
+// Unwrap returns the original pflag error
+func (e *UnknownFlagError) Unwrap() error {
+	return e.err
+}
+
+// SuggestionsFor provides suggestions for the typedName, ranked by their
+// levenshtein distance. Commands are suggested when they are close enough
+// by distance, share the typed prefix or list typedName in SuggestFor.
+func (c *Cmd) SuggestionsFor(typedName string) []string {
+	minDistance := c.SuggestionsMinimumDistance
+	if minDistance <= 0 {
+		minDistance = defaultSuggestionsMinimumDistance
+	}
+
+	distances := map[string]int{}
+	for _, cmd := range c.commands {
+		if !cmd.IsAvailableCommand() {
+			continue
+		}
+
+		name := cmd.Name()
+		distance := ld(typedName, name, true)
+		byDistance := distance <= minDistance
+		byPrefix := strings.HasPrefix(strings.ToLower(name), strings.ToLower(typedName))
+		if byDistance || byPrefix {
+			distances[name] = distance
+			continue
+		}
+
+		for _, explicit := range cmd.SuggestFor {
+			if strings.EqualFold(typedName, explicit) {
+				distances[name] = 0
+				break
+			}
+		}
+	}
+
+	return rankByDistance(distances)
+}
+
+// unknownCommandError builds the error for arg, adding suggestions unless
+// they have been disabled.
+func (c *Cmd) unknownCommandError(arg string) error {
+	err := UnknownCommandError{
+		Path: c.Path(),
+		Name: arg,
+	}
+
+	if !c.DisableSuggestions {
+		err.Suggestions = c.SuggestionsFor(arg)
+	}
+
+	return &err
+}
+
+// FlagSuggestionsFor provides flags from the full FlagSet, inherited globals
+// included, that are close to typedName. Long names are compared by their
+// levenshtein distance and prefix while a single character is matched
+// against shorthands and the first letter of long names.
+func (c *Cmd) FlagSuggestionsFor(typedName string) []string {
+	minDistance := c.SuggestionsMinimumDistance
+	if minDistance <= 0 {
+		minDistance = defaultSuggestionsMinimumDistance
+	}
+
+	c.mergeGlobalFlags()
+
+	distances := map[string]int{}
+	c.Flags().VisitAll(func(f *flag.Flag) {
+		if f.Hidden {
+			return
+		}
+
+		if len(typedName) == 1 {
+			if strings.EqualFold(f.Short, typedName) {
+				distances["-"+f.Short] = 0
+			}
+			if strings.HasPrefix(f.Name, typedName) {
+				distances["--"+f.Name] = ld(typedName, f.Name, true)
+			}
+			return
+		}
+
+		distance := ld(typedName, f.Name, true)
+		byPrefix := strings.HasPrefix(strings.ToLower(f.Name), strings.ToLower(typedName))
+		if distance <= minDistance || byPrefix {
+			distances["--"+f.Name] = distance
+		}
+	})
+
+	return rankByDistance(distances)
+}
+
+// flagSuggestionsError converts the error of a parse of args failing on a
+// flag which is not defined into an UnknownFlagError. The flag is found by
+// walking args, as the parser does. All other errors, like invalid values
+// given to known flags before it, are returned untouched.
+func (c *Cmd) flagSuggestionsError(err error, args []string) error {
+	if err == nil || failure.IsInvalidParam(err) {
+		return err
+	}
+
+	name, ok := firstUnknownFlag(args, c.Flags())
+	if !ok {
+		return err
+	}
+
+	e := UnknownFlagError{Name: name, err: err}
+	if !c.DisableSuggestions {
+		e.Suggestions = c.FlagSuggestionsFor(name)
+	}
+
+	return &e
+}
+
+// legacyArgs validation has the following behaviour:
+//   - commands with no subcommands can take arbitrary arguments
+//   - root commands with subcommands will do subcommand validity checking
+//   - subcommands with subcommands but nothing to run, the groups, will do
+//     subcommand validity checking
+//   - other subcommands will always accept arbitrary arguments
+func legacyArgs(c *Cmd, args []string) error {
+	// no subcommand, always take args
+	if !c.HasSubCommands() {
+		return nil
+	}
+
+	// root command or group with subcommands, do subcommand checking.
+	if (!c.HasParent() || !c.IsRunnable()) && len(args) > 0 {
+		return c.unknownCommandError(args[0])
+	}
+
+	return nil
+}
+
+func didYouMean(suggestions []string) string {
+	if len(suggestions) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("\n\nDid you mean this?\n")
+	for _, s := range suggestions {
+		_, _ = fmt.Fprintf(&sb, "\t%v\n", s)
+	}
+
+	return sb.String()
+}
+
+// rankByDistance returns the names ordered by ascending distance, using the
+// name itself to break ties.
+func rankByDistance(distances map[string]int) []string {
+	names := make([]string, 0, len(distances))
+	for name := range distances {
+		names = append(names, name)
+	}
+
+	sort.Slice(names, func(i, j int) bool {
+		if distances[names[i]] != distances[names[j]] {
+			return distances[names[i]] < distances[names[j]]
+		}
+		return names[i] < names[j]
+	})
+
+	return names
+}
@@ -0,0 +1,169 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestUnknownFlagError(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		flag        string
+		msg         string
+		suggestions []string
+	}{
+		{
+			name:        "long",
+			args:        []string{"--nmae", "x"},
+			flag:        "nmae",
+			msg:         "unknown flag: --nmae",
+			suggestions: []string{"--name"},
+		},
+		{
+			name:        "long with value",
+			args:        []string{"--nme=x"},
+			flag:        "nme",
+			msg:         "unknown flag: --nme",
+			suggestions: []string{"--name"},
+		},
+		{
+			name:        "shorthand",
+			args:        []string{"-x"},
+			flag:        "x",
+			msg:         `unknown shorthand flag: 'x' in -x`,
+			suggestions: []string{},
+		},
+		{
+			name:        "shorthand in a group",
+			args:        []string{"-vc"},
+			flag:        "c",
+			msg:         `unknown shorthand flag: 'c' in -c`,
+			suggestions: []string{"--color"},
+		},
+		{
+			name:        "after a flag value",
+			args:        []string{"--name", "--verbose", "--colour"},
+			flag:        "colour",
+			msg:         "unknown flag: --colour",
+			suggestions: []string{"--color"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := &Cmd{Use: "app"}
+			cmd.Flags().StringP("name", "n", "", "name")
+			cmd.Flags().BoolP("verbose", "v", false, "verbose")
+			cmd.Flags().String("color", "", "color")
+			cmd.SetRun(func(c *Cmd, args []string) error { return nil })
+			cmd.SetOutputStream(&bytes.Buffer{})
+			cmd.SetErrorStream(&bytes.Buffer{})
+			cmd.SetArgs(tt.args)
+
+			err := cmd.Execute()
+			var e *UnknownFlagError
+			if !errors.As(err, &e) {
+				t.Fatalf("expected an UnknownFlagError, got %v", err)
+			}
+
+			if e.Name != tt.flag {
+				t.Errorf("Name = %q, want %q", e.Name, tt.flag)
+			}
+
+			// pinned to the messages of the pflag version in go.mod
+			if e.Unwrap().Error() != tt.msg {
+				t.Errorf("pflag error = %q, want %q", e.Unwrap().Error(), tt.msg)
+			}
+
+			if !reflect.DeepEqual(e.Suggestions, tt.suggestions) {
+				t.Errorf("Suggestions = %q, want %q", e.Suggestions, tt.suggestions)
+			}
+		})
+	}
+}
+
+func TestUnknownFlagError_InvalidValue(t *testing.T) {
+	cmd := &Cmd{Use: "app"}
+	cmd.Flags().Int("count", 0, "count")
+	cmd.SetRun(func(c *Cmd, args []string) error { return nil })
+	cmd.SetOutputStream(&bytes.Buffer{})
+	cmd.SetErrorStream(&bytes.Buffer{})
+	cmd.SetArgs([]string{"--count", "x", "--cuont"})
+
+	err := cmd.Execute()
+	var e *UnknownFlagError
+	if err == nil || errors.As(err, &e) {
+		t.Errorf("expected the invalid value error, got %v", err)
+	}
+}
+
+func TestUnknownCommandError_Group(t *testing.T) {
+	tests := []struct {
+		name     string
+		traverse bool
+		args     []string
+		path     string
+		cmd      string
+	}{
+		{name: "root", args: []string{"remot"}, path: "app", cmd: "remote"},
+		{name: "group", args: []string{"remote", "ad"}, path: "app remote", cmd: "add"},
+		{name: "group after a flag", args: []string{"remote", "--debug", "ad"}, path: "app remote", cmd: "add"},
+		{name: "root traversed", traverse: true, args: []string{"remot"}, path: "app", cmd: "remote"},
+		{name: "group traversed", traverse: true, args: []string{"--debug", "remote", "ad"}, path: "app remote", cmd: "add"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := &Cmd{Use: "app", TraverseChildren: tt.traverse}
+			root.GlobalFlags().Bool("debug", false, "debug")
+			remote := &Cmd{Use: "remote"}
+			add := &Cmd{Use: "add"}
+			add.SetRun(func(c *Cmd, args []string) error { return nil })
+			remote.Add(add)
+			root.Add(remote)
+
+			root.SetOutputStream(&bytes.Buffer{})
+			root.SetErrorStream(&bytes.Buffer{})
+			root.SetArgs(tt.args)
+
+			err := root.Execute()
+			var e *UnknownCommandError
+			if !errors.As(err, &e) {
+				t.Fatalf("expected an UnknownCommandError, got %v", err)
+			}
+
+			if e.Path != tt.path || !reflect.DeepEqual(e.Suggestions, []string{tt.cmd}) {
+				t.Errorf("error = %+v, want path %q suggesting %q", e, tt.path, tt.cmd)
+			}
+		})
+	}
+}
+
+func TestLegacyArgs_RunnableSubcommand(t *testing.T) {
+	var got []string
+	root := &Cmd{Use: "app"}
+	remote := &Cmd{Use: "remote"}
+	remote.SetRun(func(c *Cmd, args []string) error {
+		got = args
+		return nil
+	})
+	add := &Cmd{Use: "add"}
+	add.SetRun(func(c *Cmd, args []string) error { return nil })
+	remote.Add(add)
+	root.Add(remote)
+
+	root.SetOutputStream(&bytes.Buffer{})
+	root.SetErrorStream(&bytes.Buffer{})
+	root.SetArgs([]string{"remote", "origin"})
+
+	if err := root.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(got, []string{"origin"}) {
+		t.Errorf("args = %q, want the runnable subcommand to take them", got)
+	}
+}
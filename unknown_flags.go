@@ -0,0 +1,163 @@
+package cli
+
+import (
+	flag "github.com/rsb/pflag"
+	"strings"
+)
+
+// UnknownFlags returns the unknown flags which FParseErrWhitelist let
+// through during the last parse of the flags, in the order they were given.
+// They are also kept, in place, in the args given to the Lifecycle. Unknown
+// flags are taken as booleans, a value is only part of the flag when it is
+// written as --name=value, so `--foo bar` gives the flag --foo and the
+// positional arg bar.
+func (c *Cmd) UnknownFlags() []string {
+	return c.unknownFlags
+}
+
+// extractUnknownFlags removes the unknown flags from args before they are
+// parsed. It records each of them along with the number of positional args
+// given before it, so they can be put back in place for the Lifecycle.
+func (c *Cmd) extractUnknownFlags(args []string) []string {
+	flags := c.Flags()
+	known := make([]string, 0, len(args))
+	positional := 0
+	for i := 0; i < len(args); i++ {
+		s := args[i]
+		switch {
+		case s == "--":
+			return append(known, args[i:]...)
+		case !isFlagArg(s):
+			known = append(known, s)
+			positional++
+		case isKnownFlagArg(s, flags):
+			known = append(known, s)
+			if flagArgTakesNext(s, flags) && i+1 < len(args) {
+				i++
+				known = append(known, args[i])
+			}
+		default:
+			c.unknownFlags = append(c.unknownFlags, s)
+			c.unknownFlagsAt = append(c.unknownFlagsAt, positional)
+		}
+	}
+
+	return known
+}
+
+// argsWithUnknownFlags returns the positional args with the unknown flags
+// let through by FParseErrWhitelist put back where they were given.
+func (c *Cmd) argsWithUnknownFlags(args []string) []string {
+	if len(c.unknownFlags) == 0 {
+		return args
+	}
+
+	result := make([]string, 0, len(args)+len(c.unknownFlags))
+	next := 0
+	for i, s := range c.unknownFlags {
+		at := c.unknownFlagsAt[i]
+		if at > len(args) {
+			at = len(args)
+		}
+		result = append(result, args[next:at]...)
+		result = append(result, s)
+		next = at
+	}
+
+	return append(result, args[next:]...)
+}
+
+// isKnownFlagArg determines if every flag in s is defined in flags. For a
+// group of shorthands the check stops at the first one which takes a value.
+// Malformed flags are left to the parser and count as known.
+func isKnownFlagArg(s string, flags *flag.FlagSet) bool {
+	return unknownFlagIn(s, flags) == ""
+}
+
+// unknownFlagIn returns the name of the first flag in s, without dashes,
+// which is not defined in flags or an empty string when there is none.
+func unknownFlagIn(s string, flags *flag.FlagSet) string {
+	if strings.HasPrefix(s, "--") {
+		name := strings.SplitN(s[2:], "=", 2)[0]
+		if name == "" || name[0] == '-' || name == "help" || flags.Lookup(name) != nil {
+			return ""
+		}
+		return name
+	}
+
+	shorts := s[1:]
+	for i := 0; i < len(shorts); i++ {
+		if shorts[i] == '=' {
+			return ""
+		}
+
+		f := flags.ShortLookup(shorts[i : i+1])
+		if f == nil {
+			if shorts[i] == 'h' {
+				return ""
+			}
+			return shorts[i : i+1]
+		}
+
+		if f.NoOptDefVal == "" {
+			return ""
+		}
+	}
+
+	return ""
+}
+
+// firstUnknownFlag walks args like the parser does and returns the name of
+// the first flag which is not defined in flags.
+func firstUnknownFlag(args []string, flags *flag.FlagSet) (string, bool) {
+	for i := 0; i < len(args); i++ {
+		s := args[i]
+		switch {
+		case s == "--":
+			return "", false
+		case !isFlagArg(s):
+			continue
+		}
+
+		if name := unknownFlagIn(s, flags); name != "" {
+			return name, true
+		}
+
+		if flagArgTakesNext(s, flags) {
+			i++
+		}
+	}
+
+	return "", false
+}
+
+// flagArgTakesNext determines if the known flag in s takes the next arg as
+// its value, like the parser does.
+func flagArgTakesNext(s string, flags *flag.FlagSet) bool {
+	if strings.HasPrefix(s, "--") {
+		if strings.Contains(s, "=") {
+			return false
+		}
+
+		f := flags.Lookup(s[2:])
+		return f != nil && f.NoOptDefVal == ""
+	}
+
+	shorts := s[1:]
+	for i := 0; i < len(shorts); i++ {
+		if shorts[i] == '=' {
+			return false
+		}
+
+		f := flags.ShortLookup(shorts[i : i+1])
+		if f == nil {
+			return false
+		}
+
+		if f.NoOptDefVal == "" {
+			return i == len(shorts)-1
+		}
+	}
+
+	return false
+}
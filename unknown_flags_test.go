@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestUnknownFlags(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		unknown    []string
+		lifecycle  []string
+		positional []string
+	}{
+		{
+			name:       "value after equals",
+			args:       []string{"--foo=bar", "pos"},
+			unknown:    []string{"--foo=bar"},
+			lifecycle:  []string{"--foo=bar", "pos"},
+			positional: []string{"pos"},
+		},
+		{
+			name:       "value after space is positional",
+			args:       []string{"--foo", "bar"},
+			unknown:    []string{"--foo"},
+			lifecycle:  []string{"--foo", "bar"},
+			positional: []string{"bar"},
+		},
+		{
+			name:       "followed by a positional",
+			args:       []string{"a", "--foo", "b", "c"},
+			unknown:    []string{"--foo"},
+			lifecycle:  []string{"a", "--foo", "b", "c"},
+			positional: []string{"a", "b", "c"},
+		},
+		{
+			name:       "known flags are parsed",
+			args:       []string{"--name", "x", "-z", "--verbose", "a"},
+			unknown:    []string{"-z"},
+			lifecycle:  []string{"-z", "a"},
+			positional: []string{"a"},
+		},
+		{
+			name:       "shorthand taking the next arg",
+			args:       []string{"-n", "x", "--foo", "a"},
+			unknown:    []string{"--foo"},
+			lifecycle:  []string{"--foo", "a"},
+			positional: []string{"a"},
+		},
+		{
+			name:       "after the terminator",
+			args:       []string{"--foo", "--", "--bar", "a"},
+			unknown:    []string{"--foo"},
+			lifecycle:  []string{"--foo", "--bar", "a"},
+			positional: []string{"--bar", "a"},
+		},
+		{
+			name:       "trailing",
+			args:       []string{"a", "--foo"},
+			unknown:    []string{"--foo"},
+			lifecycle:  []string{"a", "--foo"},
+			positional: []string{"a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var name string
+			var verbose bool
+			var lifecycle []string
+
+			cmd := &Cmd{Use: "wrapper", FParseErrWhitelist: FParseErrWhitelist{UnknownFlags: true}}
+			cmd.Flags().StringVarP(&name, "name", "n", "", "name")
+			cmd.Flags().BoolVar(&verbose, "verbose", false, "verbose")
+			cmd.SetRun(func(c *Cmd, args []string) error {
+				lifecycle = args
+				return nil
+			})
+			cmd.SetOutputStream(&bytes.Buffer{})
+			cmd.SetErrorStream(&bytes.Buffer{})
+			cmd.SetArgs(tt.args)
+
+			if err := cmd.Execute(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(cmd.UnknownFlags(), tt.unknown) {
+				t.Errorf("UnknownFlags() = %q, want %q", cmd.UnknownFlags(), tt.unknown)
+			}
+
+			if !reflect.DeepEqual(lifecycle, tt.lifecycle) {
+				t.Errorf("Lifecycle args = %q, want %q", lifecycle, tt.lifecycle)
+			}
+
+			if got := cmd.Flags().Args(); !reflect.DeepEqual(got, tt.positional) {
+				t.Errorf("positional args = %q, want %q", got, tt.positional)
+			}
+		})
+	}
+}
+
+func TestUnknownFlags_NotWhitelisted(t *testing.T) {
+	cmd := &Cmd{Use: "wrapper"}
+	cmd.SetRun(func(c *Cmd, args []string) error { return nil })
+	cmd.SetOutputStream(&bytes.Buffer{})
+	cmd.SetErrorStream(&bytes.Buffer{})
+	cmd.SetArgs([]string{"--foo", "bar"})
+
+	if err := cmd.Execute(); err == nil {
+		t.Fatal("expected an unknown flag error")
+	}
+
+	if len(cmd.UnknownFlags()) != 0 {
+		t.Errorf("UnknownFlags() = %q, want none", cmd.UnknownFlags())
+	}
+}
@@ -0,0 +1,275 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	flag "github.com/rsb/pflag"
+	"strings"
+)
+
+const minNamePadding = 11
+
+const defaultUsageTemplate = `Usage:{{if .IsRunnable}}
+  {{.UseLine}}{{end}}{{if .HasAvailableSubCommands}}
+  {{.Path}} [command]{{end}}{{if gt (len .Aliases) 0}}
+
+Aliases:
+  {{.NameAndAliases}}{{end}}{{if .HasExample}}
+
+Examples:
+{{.Example}}{{end}}{{if .HasDeclaredArgs}}
+
+Arguments:
+{{.ArgUsages | trimTrailingWhitespace}}{{end}}{{if .HasAvailableSubCommands}}
+
+Available Commands:{{range .Commands}}{{if .IsAvailableCommand}}
+  {{rpad .Name .NamePadding }} {{.Short}}{{end}}{{end}}{{end}}{{if .HasAvailableLocalFlags}}
+
+Flags:
+{{.LocalFlagUsages | trimTrailingWhitespace}}{{end}}{{if .HasAvailableInheritedFlags}}
+
+Global Flags:
+{{.InheritedFlagUsages | trimTrailingWhitespace}}{{end}}{{if .HasFlagGroups}}
+
+Flag Groups:
+{{.FlagGroupUsages | trimTrailingWhitespace}}{{end}}{{if .HasAvailableSubCommands}}
+
+Use "{{.Path}} [command] --help" for more information about a command.{{end}}
+`
+
+const defaultHelpTemplate = `{{if .IsDeprecated}}DEPRECATED: {{.DeprecationNotice}}
+
+{{end}}{{with (or .Long .Short)}}{{. | trimTrailingWhitespace}}
+
+{{end}}{{if or .IsRunnable .HasSubCommands}}{{.UsageString}}{{end}}`
+
+const defaultVersionTemplate = `{{with .Name}}{{printf "%s " .}}{{end}}{{printf "version %s" .Version}}
+`
+
+// SetHelpClosure assign user defined closure for help
+func (c *Cmd) SetHelpClosure(fn ControlHelpFn) {
+	c.help.Control = fn
+}
+
+// SetHelpTemplate allows the user to control the help template.
+func (c *Cmd) SetHelpTemplate(s string) {
+	c.help.Template = s
+}
+
+// SetVersionTemplate allows the user to control the version template.
+func (c *Cmd) SetVersionTemplate(s string) {
+	c.versionTemplate = s
+}
+
+// UsageFn returns the closure used to render usage, walking up the parents
+// until one is found and falling back to the usage template.
+func (c *Cmd) UsageFn() ControlUsageFn {
+	if c.usage.Control != nil {
+		return c.usage.Control
+	}
+
+	if c.HasParent() {
+		return c.parent.UsageFn()
+	}
+
+	return func(c *Cmd) error {
+		err := tpl(c.OutputStream(), c.UsageTemplate(), c)
+		if err != nil {
+			c.PrintErrln(err)
+		}
+		return err
+	}
+}
+
+// HelpFn returns the closure used to render help, walking up the parents
+// until one is found and falling back to the help template.
+func (c *Cmd) HelpFn() ControlHelpFn {
+	if c.help.Control != nil {
+		return c.help.Control
+	}
+
+	if c.HasParent() {
+		return c.parent.HelpFn()
+	}
+
+	return func(c *Cmd, a []string) {
+		err := tpl(c.OutputStream(), c.HelpTemplate(), c)
+		if err != nil {
+			c.PrintErrln(err)
+		}
+	}
+}
+
+// Usage puts out the usage for the command.
+func (c *Cmd) Usage() error {
+	return c.UsageFn()(c)
+}
+
+// Help puts out the help for the command.
+func (c *Cmd) Help() error {
+	c.HelpFn()(c, []string{})
+	return nil
+}
+
+// UsageString returns usage string.
+func (c *Cmd) UsageString() string {
+	// Storing normal writers
+	tmpOut := c.streams.out
+	tmpErr := c.streams.err
+
+	bb := new(bytes.Buffer)
+	c.SetOutputStream(bb)
+	c.SetErrorStream(bb)
+
+	CheckErr(c.Usage())
+
+	// Setting things back to normal
+	c.streams.out = tmpOut
+	c.streams.err = tmpErr
+
+	return bb.String()
+}
+
+// UsageTemplate returns usage template for the command.
+func (c *Cmd) UsageTemplate() string {
+	if c.usage.Template != "" {
+		return c.usage.Template
+	}
+
+	if c.HasParent() {
+		return c.parent.UsageTemplate()
+	}
+
+	return defaultUsageTemplate
+}
+
+// HelpTemplate return help template for the command.
+func (c *Cmd) HelpTemplate() string {
+	if c.help.Template != "" {
+		return c.help.Template
+	}
+
+	if c.HasParent() {
+		return c.parent.HelpTemplate()
+	}
+
+	return defaultHelpTemplate
+}
+
+// VersionTemplate return version template for the command.
+func (c *Cmd) VersionTemplate() string {
+	if c.versionTemplate != "" {
+		return c.versionTemplate
+	}
+
+	if c.HasParent() {
+		return c.parent.VersionTemplate()
+	}
+
+	return defaultVersionTemplate
+}
+
+// LocalFlagUsages returns the help text for the local flags
+func (c *Cmd) LocalFlagUsages() string {
+	return c.flagUsages(c.LocalFlags())
+}
+
+// InheritedFlagUsages returns the help text for the inherited flags
+func (c *Cmd) InheritedFlagUsages() string {
+	return c.flagUsages(c.InheritedFlags())
+}
+
+// flagUsages renders the usages of flags with the notes of each flag, like
+// its environment variables, added to the usage. The flags are copied so the
+// notes never leak into the flags themselves.
+func (c *Cmd) flagUsages(flags *flag.FlagSet) string {
+	out := newFlagSet(flags.Name())
+	out.SortFlags = flags.SortFlags
+	flags.VisitAll(func(f *flag.Flag) {
+		noted := *f
+		if isFlagSecret(f) {
+			noted.Default = ""
+		}
+
+		if isFlagNegatable(flags, f) {
+			noted.Name = "[" + negatedPrefix + "]" + f.Name
+		}
+		if notes := c.flagUsageNotes(f); len(notes) > 0 {
+			noted.Usage = strings.TrimSpace(noted.Usage + " " + strings.Join(notes, " "))
+		}
+		out.AddFlag(&noted)
+	})
+
+	return out.FlagUsages()
+}
+
+// flagUsageNotes returns the extra information shown in help for f
+func (c *Cmd) flagUsageNotes(f *flag.Flag) []string {
+	var notes []string
+	if v, ok := f.Value.(ValueDescriber); ok {
+		if desc := v.DescribeValue(); desc != "" {
+			notes = append(notes, "("+desc+")")
+		}
+	}
+
+	if rules := c.flagRulesUsage(f); rules != "" {
+		notes = append(notes, rules)
+	}
+
+	if env := c.envUsage(f); env != "" {
+		notes = append(notes, env)
+	}
+
+	return notes
+}
+
+// NamePadding returns padding for the name.
+func (c *Cmd) NamePadding() int {
+	if c.parent == nil || minNamePadding > c.parent.maxLength.Name {
+		return minNamePadding
+	}
+
+	return c.parent.maxLength.Name
+}
+
+// InitDefaultHelpFlag adds default help flag to c.
+// It is called automatically by executing the c or by calling help and usage.
+// If c already has help flag, it will do nothing.
+func (c *Cmd) InitDefaultHelpFlag() {
+	c.mergeGlobalFlags()
+	if c.Flags().Lookup("help") != nil {
+		return
+	}
+
+	usage := "help for "
+	if c.Name() == "" {
+		usage += "this command"
+	} else {
+		usage += c.Name()
+	}
+
+	c.Flags().BoolP("help", "h", false, usage)
+}
+
+// InitDefaultVersionFlag adds default version flag to c.
+// It is called automatically by executing the c.
+// If c already has a version flag, it will do nothing.
+// If c.Version is empty, it will do nothing.
+func (c *Cmd) InitDefaultVersionFlag() {
+	if c.Version == "" {
+		return
+	}
+
+	c.mergeGlobalFlags()
+	if c.Flags().Lookup("version") != nil {
+		return
+	}
+
+	usage := fmt.Sprintf("version for %s", c.Name())
+	if c.Flags().ShortLookup("v") == nil {
+		c.Flags().BoolP("version", "v", false, usage)
+		return
+	}
+
+	c.Flags().Bool("version", false, usage)
+}
@@ -0,0 +1,409 @@
+package cli
+
+import (
+	"github.com/rsb/failure"
+	"sort"
+	"strings"
+)
+
+const (
+	useRepeat     = "..."
+	useOptOpen    = "["
+	useOptClose   = "]"
+	useReqOpen    = "{"
+	useReqClose   = "}"
+	useAlternates = "|"
+)
+
+// UseNode is a single element of a parsed Use line. A node is either a word
+// or a group of alternatives delimited by [ ] or { }.
+//
+// Word:			the text of a word, empty for groups
+// Alternatives:	the sequences separated by | inside a group
+// Optional:		the group was delimited by [ ]
+// Repeated:		the element was followed by ...
+type UseNode struct {
+	Word         string
+	Alternatives [][]*UseNode
+	Optional     bool
+	Repeated     bool
+
+	// literal words must be typed as is, they come from a choice of words
+	literal bool
+
+	// skip is set for flags, flag values and the [flags] and [command]
+	// markers which never consume positional args.
+	skip bool
+}
+
+// IsGroup determines if the node holds alternatives instead of a word
+func (n *UseNode) IsGroup() bool {
+	return n.Alternatives != nil
+}
+
+// UseGrammar is the parsed form of Cmd.Use. See the doc comment on Cmd.Use
+// for the grammar.
+type UseGrammar struct {
+	Name  string
+	Nodes []*UseNode
+}
+
+// ParseUse parses a Use line into its grammar. An error is returned when
+// the brackets are unbalanced, a group has an empty alternative or ...
+// does not follow an element.
+func ParseUse(use string) (*UseGrammar, error) {
+	tokens := tokenizeUse(use)
+	if len(tokens) == 0 {
+		return &UseGrammar{}, nil
+	}
+
+	p := useParser{tokens: tokens[1:], use: use}
+	nodes, err := p.parseSequence()
+	if err != nil {
+		return nil, err
+	}
+
+	if tok := p.peek(); tok != "" {
+		return nil, failure.InvalidParam("malformed Use (%s): unexpected %q", use, tok)
+	}
+
+	g := UseGrammar{Name: tokens[0], Nodes: nodes}
+	markUseSequence(g.Nodes)
+
+	return &g, nil
+}
+
+// HasArgs determines if the Use line describes any positional args
+func (g *UseGrammar) HasArgs() bool {
+	min, max := boundsOfSequence(g.Nodes)
+	return min > 0 || max != 0
+}
+
+// Bounds returns the minimum and maximum number of positional args. A
+// maximum of -1 means there is no limit.
+func (g *UseGrammar) Bounds() (int, int) {
+	return boundsOfSequence(g.Nodes)
+}
+
+// Validator builds a PositionalArgs which only accepts args matching the
+// Use line.
+func (g *UseGrammar) Validator() PositionalArgs {
+	return func(cmd *Cmd, args []string) error {
+		for _, n := range matchSequence(g.Nodes, args, nil) {
+			if n == len(args) {
+				return nil
+			}
+		}
+
+		min, max := g.Bounds()
+		switch {
+		case len(args) < min:
+			return newArgsError(cmd, args, "requires at least %d arg(s), only received %d", min, len(args))
+		case max >= 0 && len(args) > max:
+			return newArgsError(cmd, args, "accepts at most %d arg(s), received %d", max, len(args))
+		default:
+			return newArgsError(cmd, args, "args do not match usage %q", cmd.Use)
+		}
+	}
+}
+
+// Completions returns the words from a choice, { } or [ ] with words
+// separated by |, that may follow args and start with toComplete.
+func (g *UseGrammar) Completions(args []string, toComplete string) []string {
+	var collected []string
+	matchSequence(g.Nodes, args, &collected)
+
+	seen := map[string]bool{}
+	var result []string
+	for _, word := range collected {
+		if seen[word] || !strings.HasPrefix(word, toComplete) {
+			continue
+		}
+		seen[word] = true
+		result = append(result, word)
+	}
+
+	return result
+}
+
+type useParser struct {
+	use    string
+	tokens []string
+	pos    int
+}
+
+func (p *useParser) peek() string {
+	if p.pos >= len(p.tokens) {
+		return ""
+	}
+
+	return p.tokens[p.pos]
+}
+
+func (p *useParser) parseSequence() ([]*UseNode, error) {
+	var seq []*UseNode
+	for {
+		tok := p.peek()
+		switch tok {
+		case "", useAlternates, useOptClose, useReqClose:
+			return seq, nil
+		case useRepeat:
+			if len(seq) == 0 {
+				return nil, failure.InvalidParam("malformed Use (%s): %q must follow an argument", p.use, useRepeat)
+			}
+			p.pos++
+			seq[len(seq)-1].Repeated = true
+		case useOptOpen, useReqOpen:
+			p.pos++
+			closer := useOptClose
+			if tok == useReqOpen {
+				closer = useReqClose
+			}
+
+			alts, err := p.parseGroup(tok, closer)
+			if err != nil {
+				return nil, err
+			}
+			seq = append(seq, &UseNode{Alternatives: alts, Optional: tok == useOptOpen})
+		default:
+			p.pos++
+			seq = append(seq, &UseNode{Word: tok})
+		}
+	}
+}
+
+func (p *useParser) parseGroup(opener, closer string) ([][]*UseNode, error) {
+	var alts [][]*UseNode
+	for {
+		seq, err := p.parseSequence()
+		if err != nil {
+			return nil, err
+		}
+
+		if len(seq) == 0 {
+			return nil, failure.InvalidParam("malformed Use (%s): empty alternative in %s%s", p.use, opener, closer)
+		}
+		alts = append(alts, seq)
+
+		tok := p.peek()
+		p.pos++
+		switch tok {
+		case useAlternates:
+			continue
+		case closer:
+			return alts, nil
+		case "":
+			return nil, failure.InvalidParam("malformed Use (%s): missing %q", p.use, closer)
+		default:
+			return nil, failure.InvalidParam("malformed Use (%s): unexpected %q, expected %q", p.use, tok, closer)
+		}
+	}
+}
+
+func tokenizeUse(use string) []string {
+	var tokens []string
+	var word strings.Builder
+	flush := func() {
+		if word.Len() > 0 {
+			tokens = append(tokens, word.String())
+			word.Reset()
+		}
+	}
+
+	for i := 0; i < len(use); i++ {
+		switch ch := use[i]; {
+		case ch == ' ' || ch == '\t':
+			flush()
+		case strings.HasPrefix(use[i:], useRepeat):
+			flush()
+			tokens = append(tokens, useRepeat)
+			i += len(useRepeat) - 1
+		case strings.ContainsRune("[]{}|", rune(ch)):
+			flush()
+			tokens = append(tokens, string(ch))
+		default:
+			word.WriteByte(ch)
+		}
+	}
+	flush()
+
+	return tokens
+}
+
+// markUseSequence flags the words which do not consume positional args and
+// the words which are literal choices.
+func markUseSequence(seq []*UseNode) {
+	for i, n := range seq {
+		if !n.IsGroup() {
+			isFlag := strings.HasPrefix(n.Word, "-")
+			followsFlag := i > 0 && !seq[i-1].IsGroup() && strings.HasPrefix(seq[i-1].Word, "-")
+			n.skip = isFlag || followsFlag
+			continue
+		}
+
+		if n.Optional && len(n.Alternatives) == 1 && len(n.Alternatives[0]) == 1 {
+			word := n.Alternatives[0][0].Word
+			if word == "flags" || word == "command" {
+				n.skip = true
+				continue
+			}
+		}
+
+		isChoice := len(n.Alternatives) > 1
+		for _, alt := range n.Alternatives {
+			markUseSequence(alt)
+			if len(alt) != 1 || alt[0].IsGroup() || alt[0].skip || strings.HasPrefix(alt[0].Word, "<") {
+				isChoice = false
+			}
+		}
+
+		if isChoice {
+			for _, alt := range n.Alternatives {
+				alt[0].literal = true
+			}
+		}
+	}
+}
+
+func boundsOfSequence(seq []*UseNode) (int, int) {
+	min, max := 0, 0
+	for _, n := range seq {
+		nMin, nMax := n.bounds()
+		min += nMin
+		if max < 0 || nMax < 0 {
+			max = -1
+		} else {
+			max += nMax
+		}
+	}
+
+	return min, max
+}
+
+func (n *UseNode) bounds() (int, int) {
+	if n.skip {
+		return 0, 0
+	}
+
+	min, max := 1, 1
+	if n.IsGroup() {
+		min, max = -1, 0
+		for _, alt := range n.Alternatives {
+			aMin, aMax := boundsOfSequence(alt)
+			if min < 0 || aMin < min {
+				min = aMin
+			}
+			if max < 0 || aMax < 0 {
+				max = -1
+			} else if aMax > max {
+				max = aMax
+			}
+		}
+	}
+
+	if n.Optional {
+		min = 0
+	}
+
+	if n.Repeated && max > 0 {
+		max = -1
+	}
+
+	return min, max
+}
+
+// matchSequence returns every number of args the sequence can consume.
+// When collected is not nil, literal words reached once all the args have
+// been consumed are appended to it.
+func matchSequence(seq []*UseNode, args []string, collected *[]string) []int {
+	positions := []int{0}
+	for _, n := range seq {
+		var next []int
+		for _, p := range positions {
+			for _, k := range n.match(args[p:], collected) {
+				next = append(next, p+k)
+			}
+		}
+
+		positions = uniqueInts(next)
+		if len(positions) == 0 {
+			return nil
+		}
+	}
+
+	return positions
+}
+
+func (n *UseNode) match(args []string, collected *[]string) []int {
+	first := n.matchOnce(args, collected)
+	if !n.Repeated {
+		return first
+	}
+
+	seen := map[int]bool{}
+	queue := first
+	for len(queue) > 0 {
+		k := queue[0]
+		queue = queue[1:]
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+
+		// only keep repeating while args are consumed
+		if k == 0 {
+			continue
+		}
+		for _, m := range n.matchOnce(args[k:], collected) {
+			if m > 0 {
+				queue = append(queue, k+m)
+			}
+		}
+	}
+
+	var result []int
+	for k := range seen {
+		result = append(result, k)
+	}
+
+	return uniqueInts(result)
+}
+
+func (n *UseNode) matchOnce(args []string, collected *[]string) []int {
+	switch {
+	case n.skip:
+		return []int{0}
+	case n.IsGroup():
+		var result []int
+		if n.Optional {
+			result = append(result, 0)
+		}
+		for _, alt := range n.Alternatives {
+			result = append(result, matchSequence(alt, args, collected)...)
+		}
+		return uniqueInts(result)
+	case len(args) == 0:
+		if n.literal && collected != nil {
+			*collected = append(*collected, n.Word)
+		}
+		return nil
+	case n.literal && args[0] != n.Word:
+		return nil
+	default:
+		return []int{1}
+	}
+}
+
+func uniqueInts(in []int) []int {
+	seen := map[int]bool{}
+	var result []int
+	for _, i := range in {
+		if !seen[i] {
+			seen[i] = true
+			result = append(result, i)
+		}
+	}
+	sort.Ints(result)
+
+	return result
+}
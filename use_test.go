@@ -0,0 +1,254 @@
+package cli
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseUse_Bounds(t *testing.T) {
+	tests := []struct {
+		use      string
+		min, max int
+		hasArgs  bool
+	}{
+		{use: "", min: 0, max: 0},
+		{use: "app", min: 0, max: 0},
+		{use: "app [flags]", min: 0, max: 0},
+		{use: "app [command]", min: 0, max: 0},
+		{use: "app -f format", min: 0, max: 0},
+		{use: "app name", min: 1, max: 1, hasArgs: true},
+		{use: "app src dst", min: 2, max: 2, hasArgs: true},
+		{use: "app [name]", min: 0, max: 1, hasArgs: true},
+		{use: "app file...", min: 1, max: -1, hasArgs: true},
+		{use: "app [file]...", min: 0, max: -1, hasArgs: true},
+		{use: "app [file...]", min: 0, max: -1, hasArgs: true},
+		{use: "app {start|stop}", min: 1, max: 1, hasArgs: true},
+		{use: "app [start|stop]", min: 0, max: 1, hasArgs: true},
+		{use: "app {a | b c}", min: 1, max: 2, hasArgs: true},
+		{use: "app [-F file | -D dir]... [-f format] profile", min: 1, max: 1, hasArgs: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.use, func(t *testing.T) {
+			g, err := ParseUse(tt.use)
+			if err != nil {
+				t.Fatalf("ParseUse(%q) unexpected error: %v", tt.use, err)
+			}
+
+			min, max := g.Bounds()
+			if min != tt.min || max != tt.max {
+				t.Errorf("Bounds() = (%d, %d), want (%d, %d)", min, max, tt.min, tt.max)
+			}
+
+			if g.HasArgs() != tt.hasArgs {
+				t.Errorf("HasArgs() = %v, want %v", g.HasArgs(), tt.hasArgs)
+			}
+		})
+	}
+}
+
+func TestParseUse_Nodes(t *testing.T) {
+	g, err := ParseUse("app {start|stop} [name]... dir")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if g.Name != "app" {
+		t.Errorf("Name = %q, want %q", g.Name, "app")
+	}
+
+	if len(g.Nodes) != 3 {
+		t.Fatalf("len(Nodes) = %d, want 3", len(g.Nodes))
+	}
+
+	choice, name, dir := g.Nodes[0], g.Nodes[1], g.Nodes[2]
+	if !choice.IsGroup() || choice.Optional || choice.Repeated || len(choice.Alternatives) != 2 {
+		t.Errorf("choice node = %+v, want a required group with 2 alternatives", choice)
+	}
+
+	if !name.IsGroup() || !name.Optional || !name.Repeated {
+		t.Errorf("name node = %+v, want an optional repeated group", name)
+	}
+
+	if dir.IsGroup() || dir.Word != "dir" {
+		t.Errorf("dir node = %+v, want the word dir", dir)
+	}
+}
+
+func TestParseUse_Malformed(t *testing.T) {
+	tests := []struct {
+		use  string
+		want string
+	}{
+		{use: "app [name", want: `missing "]"`},
+		{use: "app {a|b", want: `missing "}"`},
+		{use: "app [a}", want: `unexpected "}", expected "]"`},
+		{use: "app name]", want: `unexpected "]"`},
+		{use: "app ...", want: `"..." must follow an argument`},
+		{use: "app [...]", want: `"..." must follow an argument`},
+		{use: "app [a||b]", want: "empty alternative in []"},
+		{use: "app {}", want: "empty alternative in {}"},
+		{use: "app a | b", want: `unexpected "|"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.use, func(t *testing.T) {
+			_, err := ParseUse(tt.use)
+			if err == nil {
+				t.Fatalf("ParseUse(%q) expected an error", tt.use)
+			}
+
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %q, want it to contain %q", err.Error(), tt.want)
+			}
+		})
+	}
+}
+
+func TestUseGrammar_Validator(t *testing.T) {
+	tests := []struct {
+		use  string
+		args []string
+		want string
+	}{
+		{use: "app", args: nil},
+		{use: "app", args: []string{"x"}, want: "accepts at most 0 arg(s), received 1"},
+		{use: "app name", args: []string{"x"}},
+		{use: "app name", args: nil, want: "requires at least 1 arg(s), only received 0"},
+		{use: "app [name]", args: nil},
+		{use: "app [name]", args: []string{"x", "y"}, want: "accepts at most 1 arg(s), received 2"},
+		{use: "app file...", args: []string{"a", "b", "c"}},
+		{use: "app file...", args: nil, want: "requires at least 1 arg(s), only received 0"},
+		{use: "app {start|stop}", args: []string{"start"}},
+		{use: "app {start|stop}", args: []string{"restart"}, want: `args do not match usage "app {start|stop}"`},
+		{use: "app [start|stop] name", args: []string{"stop", "web"}},
+		{use: "app [start|stop] name", args: []string{"web"}},
+		{use: "app {add <name> | list}", args: []string{"add", "x"}},
+		{use: "app {add <name> | list}", args: []string{"list"}},
+		{use: "app [-f format] profile", args: []string{"dev"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.use+" "+strings.Join(tt.args, " "), func(t *testing.T) {
+			g, err := ParseUse(tt.use)
+			if err != nil {
+				t.Fatalf("ParseUse(%q) unexpected error: %v", tt.use, err)
+			}
+
+			cmd := &Cmd{Use: tt.use}
+			err = g.Validator()(cmd, tt.args)
+			switch {
+			case tt.want == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case tt.want != "" && err == nil:
+				t.Errorf("expected an error containing %q", tt.want)
+			case tt.want != "" && !strings.Contains(err.Error(), tt.want):
+				t.Errorf("error = %q, want it to contain %q", err.Error(), tt.want)
+			}
+		})
+	}
+}
+
+func TestUseGrammar_Completions(t *testing.T) {
+	tests := []struct {
+		use        string
+		args       []string
+		toComplete string
+		want       []string
+	}{
+		{use: "app {start|stop|status}", want: []string{"start", "stop", "status"}},
+		{use: "app {start|stop|status}", toComplete: "st", want: []string{"start", "stop", "status"}},
+		{use: "app {start|stop|status}", toComplete: "sta", want: []string{"start", "status"}},
+		{use: "app {start|stop}", args: []string{"start"}, want: nil},
+		{use: "app {start|stop} {fast|slow}", args: []string{"stop"}, want: []string{"fast", "slow"}},
+		{use: "app [on|off]...", args: []string{"on"}, want: []string{"on", "off"}},
+		{use: "app name", want: nil},
+		{use: "app {<a>|<b>}", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.use+" "+strings.Join(tt.args, " "), func(t *testing.T) {
+			g, err := ParseUse(tt.use)
+			if err != nil {
+				t.Fatalf("ParseUse(%q) unexpected error: %v", tt.use, err)
+			}
+
+			got := g.Completions(tt.args, tt.toComplete)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Completions(%q, %q) = %q, want %q", tt.args, tt.toComplete, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateArgs_DerivedFromUse(t *testing.T) {
+	tests := []struct {
+		name string
+		cmd  *Cmd
+		args []string
+		err  bool
+	}{
+		{name: "choice", cmd: &Cmd{Use: "app {start|stop}"}, args: []string{"start"}},
+		{name: "not a choice", cmd: &Cmd{Use: "app {start|stop}"}, args: []string{"restart"}, err: true},
+		{name: "name only", cmd: &Cmd{Use: "app"}, args: []string{"a", "b"}},
+		{name: "Args set", cmd: &Cmd{Use: "app {start|stop}", Args: ArbitraryArgs}, args: []string{"restart"}},
+		{name: "UseArgs", cmd: &Cmd{Use: "app {start|stop}", Args: UseArgs}, args: []string{"restart"}, err: true},
+		{name: "flag parsing disabled", cmd: &Cmd{Use: "app {start|stop}", DisableFlagParsing: true}, args: []string{"x"}},
+		{name: "malformed", cmd: &Cmd{Use: "app [name"}, args: []string{}, err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cmd.ValidateArgs(tt.args)
+			if tt.err != (err != nil) {
+				t.Errorf("ValidateArgs(%q) = %v, want error %v", tt.args, err, tt.err)
+			}
+		})
+	}
+}
+
+func TestArgCompletions_Use(t *testing.T) {
+	tests := []struct {
+		name string
+		args PositionalArgs
+	}{
+		{name: "derived"},
+		{name: "UseArgs", args: UseArgs},
+		{name: "combined", args: MatchAll(UseArgs, MaximumNArgs(2))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := &Cmd{Use: "app {start|stop}", Args: tt.args}
+			got, directive, ok := cmd.argCompletions([]string{}, "st")
+			if !ok || !reflect.DeepEqual(got, []string{"start", "stop"}) || directive != ShellCompDirectiveNoFileComp {
+				t.Errorf("argCompletions = %q, %v, %v", got, directive, ok)
+			}
+		})
+	}
+}
+
+func TestValidateTree_MalformedUse(t *testing.T) {
+	root := &Cmd{Use: "root"}
+	child := &Cmd{Use: "child [name"}
+	child.SetRun(func(c *Cmd, args []string) error { return nil })
+	root.Add(child, &Cmd{Use: "other {a|"})
+
+	if len(root.Commands()) != 2 {
+		t.Fatal("expected Add to accept the children without panicking")
+	}
+
+	err := root.ValidateTree()
+	if err == nil || !strings.Contains(err.Error(), "root child") || !strings.Contains(err.Error(), "root other") {
+		t.Fatalf("ValidateTree() = %v, want both malformed commands reported", err)
+	}
+
+	root.SetOutputStream(&bytes.Buffer{})
+	root.SetErrorStream(&bytes.Buffer{})
+	root.SetArgs([]string{"child"})
+	if _, err := root.ExecuteC(); err == nil {
+		t.Error("expected ExecuteC to report the malformed Use")
+	}
+}
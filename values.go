@@ -0,0 +1,549 @@
+package cli
+
+import (
+	"fmt"
+	flag "github.com/rsb/pflag"
+	"math"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ValueCompleter is implemented by flag values which know the values they
+// accept. Their completions are used when no other completion is set up for
+// the flag.
+type ValueCompleter interface {
+	CompleteValue(toComplete string) ([]string, ShellCompDirective)
+}
+
+// ValueDescriber is implemented by flag values whose constraints should be
+// described next to the usage of the flag in help.
+type ValueDescriber interface {
+	DescribeValue() string
+}
+
+// Enum is a string flag value restricted to a set of allowed values. The
+// allowed values are shown in help and offered by shell completion.
+//
+//	flags.Var(cli.NewEnum(&format, "json", "json", "yaml", "text"), "format", "output format")
+type Enum struct {
+	p       *string
+	allowed []string
+}
+
+// NewEnum creates an Enum writing into p, starting with value which must be
+// one of allowed, an empty value means there is no default.
+func NewEnum(p *string, value string, allowed ...string) *Enum {
+	e := &Enum{p: p, allowed: allowed}
+	*p = ""
+	if value != "" {
+		if err := e.Set(value); err != nil {
+			panic(fmt.Sprintf("enum default %q: %v", value, err))
+		}
+	}
+
+	return e
+}
+
+// Allowed returns the values accepted by the enum
+func (e *Enum) Allowed() []string {
+	return e.allowed
+}
+
+func (e *Enum) String() string {
+	return *e.p
+}
+
+func (e *Enum) Set(s string) error {
+	if !stringInSlice(s, e.allowed) {
+		return fmt.Errorf("must be one of %s", strings.Join(e.allowed, "|"))
+	}
+
+	*e.p = s
+	return nil
+}
+
+// Type returns the allowed values so help reads --format json|yaml|text
+func (e *Enum) Type() string {
+	return strings.Join(e.allowed, "|")
+}
+
+func (e *Enum) CompleteValue(toComplete string) ([]string, ShellCompDirective) {
+	var completions []string
+	for _, v := range e.allowed {
+		if strings.HasPrefix(v, toComplete) {
+			completions = append(completions, v)
+		}
+	}
+
+	return completions, ShellCompDirectiveNoFileComp
+}
+
+var byteSizeUnits = map[string]uint64{
+	"":    1,
+	"b":   1,
+	"k":   1000,
+	"kb":  1000,
+	"kib": 1 << 10,
+	"m":   1000 * 1000,
+	"mb":  1000 * 1000,
+	"mib": 1 << 20,
+	"g":   1000 * 1000 * 1000,
+	"gb":  1000 * 1000 * 1000,
+	"gib": 1 << 30,
+	"t":   1000 * 1000 * 1000 * 1000,
+	"tb":  1000 * 1000 * 1000 * 1000,
+	"tib": 1 << 40,
+}
+
+// ByteSize is a size in bytes given with an optional decimal (kB, MB, GB,
+// TB) or binary (KiB, MiB, GiB, TiB) unit, like 512, 1.5GB or 10MiB.
+type ByteSize struct {
+	p *uint64
+}
+
+// NewByteSize creates a ByteSize writing into p, starting with value
+func NewByteSize(p *uint64, value uint64) *ByteSize {
+	*p = value
+	return &ByteSize{p: p}
+}
+
+// ParseByteSize parses a size like 10MiB into a number of bytes
+func ParseByteSize(s string) (uint64, error) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if i < 0 {
+		i = len(s)
+	}
+
+	number, unit := s[:i], strings.ToLower(strings.TrimSpace(s[i:]))
+	multiplier, ok := byteSizeUnits[unit]
+	if !ok {
+		return 0, fmt.Errorf("unknown size unit %q", s[i:])
+	}
+
+	n, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+
+	size := n * float64(multiplier)
+	// MaxUint64 rounds up to 2^64 as a float64, which does not fit
+	if size >= math.MaxUint64 {
+		return 0, fmt.Errorf("size %q is too large", s)
+	}
+
+	return uint64(size), nil
+}
+
+// FormatByteSize writes size with the largest unit dividing it exactly,
+// preferring binary units.
+func FormatByteSize(size uint64) string {
+	if size == 0 {
+		return "0B"
+	}
+
+	units := []struct {
+		name string
+		size uint64
+	}{
+		{"TiB", 1 << 40}, {"TB", 1000 * 1000 * 1000 * 1000},
+		{"GiB", 1 << 30}, {"GB", 1000 * 1000 * 1000},
+		{"MiB", 1 << 20}, {"MB", 1000 * 1000},
+		{"KiB", 1 << 10}, {"kB", 1000},
+	}
+	for _, u := range units {
+		if size%u.size == 0 {
+			return fmt.Sprintf("%d%s", size/u.size, u.name)
+		}
+	}
+
+	return fmt.Sprintf("%dB", size)
+}
+
+func (b *ByteSize) String() string {
+	return FormatByteSize(*b.p)
+}
+
+func (b *ByteSize) Set(s string) error {
+	size, err := ParseByteSize(s)
+	if err != nil {
+		return err
+	}
+
+	*b.p = size
+	return nil
+}
+
+func (b *ByteSize) Type() string {
+	return "size"
+}
+
+// IntRange is an int flag value which must be within [Min, Max]
+type IntRange struct {
+	p        *int
+	Min, Max int
+}
+
+// NewIntRange creates an IntRange writing into p, starting with value
+func NewIntRange(p *int, value, min, max int) *IntRange {
+	*p = value
+	return &IntRange{p: p, Min: min, Max: max}
+}
+
+func (r *IntRange) String() string {
+	return strconv.Itoa(*r.p)
+}
+
+func (r *IntRange) Set(s string) error {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return fmt.Errorf("%q is not an integer", s)
+	}
+
+	if n < r.Min || n > r.Max {
+		return fmt.Errorf("%d is not between %d and %d", n, r.Min, r.Max)
+	}
+
+	*r.p = n
+	return nil
+}
+
+func (r *IntRange) Type() string {
+	return "int"
+}
+
+func (r *IntRange) DescribeValue() string {
+	return fmt.Sprintf("between %d and %d", r.Min, r.Max)
+}
+
+// FloatRange is a float64 flag value which must be within [Min, Max]
+type FloatRange struct {
+	p        *float64
+	Min, Max float64
+}
+
+// NewFloatRange creates a FloatRange writing into p, starting with value
+func NewFloatRange(p *float64, value, min, max float64) *FloatRange {
+	*p = value
+	return &FloatRange{p: p, Min: min, Max: max}
+}
+
+func (r *FloatRange) String() string {
+	return strconv.FormatFloat(*r.p, 'g', -1, 64)
+}
+
+func (r *FloatRange) Set(s string) error {
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return fmt.Errorf("%q is not a number", s)
+	}
+
+	if n < r.Min || n > r.Max {
+		return fmt.Errorf("%g is not between %g and %g", n, r.Min, r.Max)
+	}
+
+	*r.p = n
+	return nil
+}
+
+func (r *FloatRange) Type() string {
+	return "float"
+}
+
+func (r *FloatRange) DescribeValue() string {
+	return fmt.Sprintf("between %g and %g", r.Min, r.Max)
+}
+
+// countIncrement is the value given to a Count flag without a value
+const countIncrement = "+1"
+
+// Count is an int flag value incremented each time the flag is given without
+// a value, -vvv and -v -v -v both count 3. A value sets the count, like
+// --verbose=2 or a count read from the environment.
+type Count struct {
+	p *int
+}
+
+// NewCount creates a Count writing into p, starting with value. The flag
+// must be defined with CountVarP, or given a NoOptDefVal of "+1", to count.
+func NewCount(p *int, value int) *Count {
+	*p = value
+	return &Count{p: p}
+}
+
+// CountVarP defines a Count flag in flags
+func CountVarP(flags *flag.FlagSet, p *int, name, short, usage string) {
+	f := flags.VarPF(NewCount(p, 0), name, short, usage)
+	f.NoOptDefVal = countIncrement
+}
+
+func (c *Count) String() string {
+	return strconv.Itoa(*c.p)
+}
+
+func (c *Count) Set(s string) error {
+	if s == countIncrement {
+		*c.p++
+		return nil
+	}
+
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 {
+		return fmt.Errorf("%q is not a count", s)
+	}
+
+	*c.p = n
+	return nil
+}
+
+// Type matches the count flags of pflag so help renders them alike
+func (c *Count) Type() string {
+	return "count"
+}
+
+// KeyValue is a map flag value given one key=value pair at a time, the flag
+// is repeated for more pairs. Unlike the stringToString flags of pflag the
+// values may contain commas.
+type KeyValue struct {
+	p       *map[string]string
+	changed bool
+}
+
+// NewKeyValue creates a KeyValue writing into p, starting with value
+func NewKeyValue(p *map[string]string, value map[string]string) *KeyValue {
+	*p = map[string]string{}
+	for k, v := range value {
+		(*p)[k] = v
+	}
+
+	return &KeyValue{p: p}
+}
+
+func (kv *KeyValue) String() string {
+	pairs := make([]string, 0, len(*kv.p))
+	for k, v := range *kv.p {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+
+	return "[" + strings.Join(pairs, ",") + "]"
+}
+
+func (kv *KeyValue) Set(s string) error {
+	k, v, ok := strings.Cut(s, "=")
+	if !ok || k == "" {
+		return fmt.Errorf("%q must be formatted as key=value", s)
+	}
+
+	// the first value replaces the default
+	if !kv.changed {
+		*kv.p = map[string]string{}
+		kv.changed = true
+	}
+
+	(*kv.p)[k] = v
+	return nil
+}
+
+func (kv *KeyValue) Type() string {
+	return "key=value"
+}
+
+// Regexp is a flag value compiled as a regular expression
+type Regexp struct {
+	p **regexp.Regexp
+}
+
+// NewRegexp creates a Regexp writing into p, starting with value which must
+// compile, an empty value leaves p nil.
+func NewRegexp(p **regexp.Regexp, value string) *Regexp {
+	*p = nil
+	if value != "" {
+		*p = regexp.MustCompile(value)
+	}
+
+	return &Regexp{p: p}
+}
+
+func (r *Regexp) String() string {
+	if *r.p == nil {
+		return ""
+	}
+
+	return (*r.p).String()
+}
+
+func (r *Regexp) Set(s string) error {
+	re, err := regexp.Compile(s)
+	if err != nil {
+		return fmt.Errorf("invalid regexp: %v", err)
+	}
+
+	*r.p = re
+	return nil
+}
+
+func (r *Regexp) Type() string {
+	return "regexp"
+}
+
+// URL is a flag value parsed as an absolute URL, optionally restricted to a
+// set of schemes.
+type URL struct {
+	p       **url.URL
+	schemes []string
+}
+
+// NewURL creates a URL writing into p, starting with value which must parse,
+// an empty value leaves p nil.
+func NewURL(p **url.URL, value string, schemes ...string) *URL {
+	u := &URL{p: p, schemes: schemes}
+	*p = nil
+	if value != "" {
+		if err := u.Set(value); err != nil {
+			panic(err)
+		}
+	}
+
+	return u
+}
+
+func (u *URL) String() string {
+	if *u.p == nil {
+		return ""
+	}
+
+	return (*u.p).String()
+}
+
+func (u *URL) Set(s string) error {
+	parsed, err := url.Parse(s)
+	if err != nil {
+		return fmt.Errorf("invalid url: %v", err)
+	}
+
+	if !parsed.IsAbs() {
+		return fmt.Errorf("url %q has no scheme", s)
+	}
+
+	if len(u.schemes) > 0 && !stringInSlice(parsed.Scheme, u.schemes) {
+		return fmt.Errorf("url scheme must be one of %s", strings.Join(u.schemes, "|"))
+	}
+
+	*u.p = parsed
+	return nil
+}
+
+func (u *URL) Type() string {
+	return "url"
+}
+
+func (u *URL) DescribeValue() string {
+	if len(u.schemes) == 0 {
+		return ""
+	}
+
+	return "scheme " + strings.Join(u.schemes, "|")
+}
+
+// DefaultTimeLayouts are the layouts tried by Time when none are given
+var DefaultTimeLayouts = []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02"}
+
+// Time is a flag value parsed with the first matching layout. It is written
+// with the first layout.
+type Time struct {
+	p       *time.Time
+	layouts []string
+}
+
+// NewTime creates a Time writing into p, starting with value
+func NewTime(p *time.Time, value time.Time, layouts ...string) *Time {
+	if len(layouts) == 0 {
+		layouts = DefaultTimeLayouts
+	}
+
+	*p = value
+	return &Time{p: p, layouts: layouts}
+}
+
+func (t *Time) String() string {
+	if t.p.IsZero() {
+		return ""
+	}
+
+	return t.p.Format(t.layouts[0])
+}
+
+func (t *Time) Set(s string) error {
+	for _, layout := range t.layouts {
+		if parsed, err := time.Parse(layout, s); err == nil {
+			*t.p = parsed
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%q does not match the layouts %s", s, strings.Join(t.layouts, ", "))
+}
+
+func (t *Time) Type() string {
+	return "time"
+}
+
+func (t *Time) DescribeValue() string {
+	return "layout " + strings.Join(t.layouts, ", ")
+}
+
+// FileContent is a string flag value which reads the content of a file when
+// the value starts with @, like --body=@payload.json. A value starting with
+// @@ is taken literally without the first @.
+type FileContent struct {
+	p    *string
+	path string
+}
+
+// NewFileContent creates a FileContent writing into p, starting with value
+func NewFileContent(p *string, value string) *FileContent {
+	*p = value
+	return &FileContent{p: p}
+}
+
+// Path returns the file the value was read from, empty for a literal value
+func (fc *FileContent) Path() string {
+	return fc.path
+}
+
+func (fc *FileContent) String() string {
+	if fc.path != "" {
+		return "@" + fc.path
+	}
+
+	return *fc.p
+}
+
+func (fc *FileContent) Set(s string) error {
+	switch {
+	case strings.HasPrefix(s, "@@"):
+		*fc.p, fc.path = s[1:], ""
+	case strings.HasPrefix(s, "@"):
+		data, err := os.ReadFile(s[1:])
+		if err != nil {
+			return fmt.Errorf("reading flag value from (%s): %w", s[1:], err)
+		}
+		*fc.p, fc.path = string(data), s[1:]
+	default:
+		*fc.p, fc.path = s, ""
+	}
+
+	return nil
+}
+
+func (fc *FileContent) Type() string {
+	return "string|@file"
+}
@@ -0,0 +1,234 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want uint64
+		err  string
+	}{
+		{in: "0", want: 0},
+		{in: "512", want: 512},
+		{in: "512B", want: 512},
+		{in: "1k", want: 1000},
+		{in: "1kB", want: 1000},
+		{in: "1KiB", want: 1024},
+		{in: "1.5MiB", want: 3 << 19},
+		{in: "2 GB", want: 2000 * 1000 * 1000},
+		{in: " 1tib ", want: 1 << 40},
+		{in: "16777215TiB", want: 16777215 << 40},
+		{in: "16777216TiB", err: "too large"},
+		{in: "1e3", err: "unknown size unit"},
+		{in: "10XB", err: "unknown size unit"},
+		{in: "MiB", err: "invalid size"},
+		{in: "1.2.3k", err: "invalid size"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseByteSize(tt.in)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("ParseByteSize(%q) error = %v, want it to contain %q", tt.in, err, tt.err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("ParseByteSize(%q) unexpected error: %v", tt.in, err)
+			}
+
+			if got != tt.want {
+				t.Errorf("ParseByteSize(%q) = %d, want %d", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatByteSize(t *testing.T) {
+	tests := []struct {
+		in   uint64
+		want string
+	}{
+		{in: 0, want: "0B"},
+		{in: 1, want: "1B"},
+		{in: 1000, want: "1kB"},
+		{in: 1024, want: "1KiB"},
+		{in: 3 << 20, want: "3MiB"},
+		{in: 5 * 1000 * 1000 * 1000, want: "5GB"},
+		{in: 1 << 40, want: "1TiB"},
+		{in: 1001, want: "1001B"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatByteSize(tt.in); got != tt.want {
+			t.Errorf("FormatByteSize(%d) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestEnum(t *testing.T) {
+	var format string
+	e := NewEnum(&format, "json", "json", "yaml")
+	if format != "json" {
+		t.Errorf("default = %q, want json", format)
+	}
+
+	if err := e.Set("yaml"); err != nil || format != "yaml" {
+		t.Errorf("Set(yaml) = %v, value %q", err, format)
+	}
+
+	if err := e.Set("xml"); err == nil || format != "yaml" {
+		t.Errorf("Set(xml) = %v, value %q, want an error and the value kept", err, format)
+	}
+
+	if e.Type() != "json|yaml" {
+		t.Errorf("Type() = %q", e.Type())
+	}
+
+	completions, directive := e.CompleteValue("y")
+	if !reflect.DeepEqual(completions, []string{"yaml"}) || directive != ShellCompDirectiveNoFileComp {
+		t.Errorf("CompleteValue(y) = %q, %v", completions, directive)
+	}
+
+	NewEnum(&format, "", "json", "yaml")
+	if format != "" {
+		t.Errorf("empty default = %q, want none", format)
+	}
+}
+
+func TestNewEnum_BadDefault(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected NewEnum to panic on a default which is not allowed")
+		}
+	}()
+
+	var format string
+	NewEnum(&format, "xml", "json", "yaml")
+}
+
+func TestRanges(t *testing.T) {
+	var i int
+	ir := NewIntRange(&i, 5, 1, 10)
+	for _, s := range []string{"0", "11", "x"} {
+		if err := ir.Set(s); err == nil {
+			t.Errorf("IntRange.Set(%q) expected an error", s)
+		}
+	}
+	if err := ir.Set("10"); err != nil || i != 10 {
+		t.Errorf("IntRange.Set(10) = %v, value %d", err, i)
+	}
+
+	var f float64
+	fr := NewFloatRange(&f, 0.5, 0, 1)
+	for _, s := range []string{"-0.1", "1.1", "x"} {
+		if err := fr.Set(s); err == nil {
+			t.Errorf("FloatRange.Set(%q) expected an error", s)
+		}
+	}
+	if err := fr.Set("0.25"); err != nil || f != 0.25 {
+		t.Errorf("FloatRange.Set(0.25) = %v, value %g", err, f)
+	}
+}
+
+func TestCount(t *testing.T) {
+	var n int
+	c := NewCount(&n, 0)
+	for _, s := range []string{countIncrement, countIncrement} {
+		if err := c.Set(s); err != nil {
+			t.Fatalf("Set(%q) unexpected error: %v", s, err)
+		}
+	}
+	if n != 2 {
+		t.Errorf("count = %d, want 2", n)
+	}
+
+	if err := c.Set("5"); err != nil || n != 5 {
+		t.Errorf("Set(5) = %v, value %d", err, n)
+	}
+
+	if err := c.Set("-1"); err == nil {
+		t.Error("Set(-1) expected an error")
+	}
+}
+
+func TestKeyValue(t *testing.T) {
+	var m map[string]string
+	kv := NewKeyValue(&m, map[string]string{"a": "1"})
+	for _, s := range []string{"b=2,3", "c="} {
+		if err := kv.Set(s); err != nil {
+			t.Fatalf("Set(%q) unexpected error: %v", s, err)
+		}
+	}
+
+	want := map[string]string{"b": "2,3", "c": ""}
+	if !reflect.DeepEqual(m, want) {
+		t.Errorf("value = %v, want %v", m, want)
+	}
+
+	if kv.String() != "[b=2,3,c=]" {
+		t.Errorf("String() = %q", kv.String())
+	}
+
+	for _, s := range []string{"b", "=1"} {
+		if err := kv.Set(s); err == nil {
+			t.Errorf("Set(%q) expected an error", s)
+		}
+	}
+}
+
+func TestTime(t *testing.T) {
+	var v time.Time
+	tm := NewTime(&v, time.Time{})
+	if err := tm.Set("2022-06-11"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := time.Date(2022, 6, 11, 0, 0, 0, 0, time.UTC); !v.Equal(want) {
+		t.Errorf("value = %v, want %v", v, want)
+	}
+
+	if err := tm.Set("11/06/2022"); err == nil {
+		t.Error("expected an error for a value matching no layout")
+	}
+}
+
+func TestFileContent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "body.json")
+	if err := os.WriteFile(path, []byte(`{"a":1}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var v string
+	fc := NewFileContent(&v, "")
+	tests := []struct {
+		in, want, path string
+	}{
+		{in: "literal", want: "literal"},
+		{in: "@" + path, want: `{"a":1}`, path: path},
+		{in: "@@handle", want: "@handle"},
+	}
+
+	for _, tt := range tests {
+		if err := fc.Set(tt.in); err != nil {
+			t.Fatalf("Set(%q) unexpected error: %v", tt.in, err)
+		}
+
+		if v != tt.want || fc.Path() != tt.path {
+			t.Errorf("Set(%q) = %q from %q, want %q from %q", tt.in, v, fc.Path(), tt.want, tt.path)
+		}
+	}
+
+	if err := fc.Set("@" + path + ".missing"); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
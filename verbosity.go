@@ -0,0 +1,85 @@
+package cli
+
+const (
+	verboseFlagName = "verbose"
+	quietFlagName   = "quiet"
+)
+
+// EnableVerbosityFlags adds the global --verbose/-v and --quiet/-q count
+// flags to the root command when the command tree executes. Each --verbose
+// lowers the LogLevel of the Streams of every command by one level and each
+// --quiet raises it, so -v logs info messages, -vv debug messages and -q only
+// errors. The shorthands are only taken when no command of the tree defines
+// them, the version flag then goes without -v.
+func (c *Cmd) EnableVerbosityFlags() {
+	c.Root().verbosityFlags = true
+}
+
+// initVerbosityFlags adds the flags enabled by EnableVerbosityFlags. It is
+// called on the root once the tree is complete so the shorthands can be
+// checked against every command.
+func (c *Cmd) initVerbosityFlags() {
+	flags := c.GlobalFlags()
+	if !c.verbosityFlags || flags.Lookup(verboseFlagName) != nil {
+		return
+	}
+
+	short := func(s string) string {
+		if c.isShorthandUsed(s) {
+			return ""
+		}
+		return s
+	}
+
+	CountVarP(flags, &c.verbose, verboseFlagName, short("v"), "log more, repeat for more details")
+	CountVarP(flags, &c.quiet, quietFlagName, short("q"), "log less, repeat to log nothing")
+}
+
+// isShorthandUsed determines if c or any of its subcommands defines the
+// shorthand s, locally or globally.
+func (c *Cmd) isShorthandUsed(s string) bool {
+	if c.Flags().ShortLookup(s) != nil || c.GlobalFlags().ShortLookup(s) != nil {
+		return true
+	}
+
+	for _, cmd := range c.commands {
+		if cmd.isShorthandUsed(s) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SetLogLevel assigns the LogLevel of the command tree before --verbose and
+// --quiet are applied. It is stored on the root command.
+func (c *Cmd) SetLogLevel(l LogLevel) {
+	c.Root().streams.SetLogLevel(l)
+}
+
+// LogLevel returns the LogLevel of the command tree, adjusted by --verbose
+// and --quiet.
+func (c *Cmd) LogLevel() LogLevel {
+	root := c.Root()
+	l := root.streams.LogLevel() + LogLevel(root.quiet-root.verbose)
+	switch {
+	case l < LogDebug:
+		return LogDebug
+	case l > LogSilent:
+		return LogSilent
+	default:
+		return l
+	}
+}
+
+// Streams returns the input, output and error streams of the command, with
+// the ones inherited from the parents, at the LogLevel of the command tree.
+// Use it to log consistently from any command.
+//
+//	c.Streams().Debugf("loaded %d items", n)
+func (c *Cmd) Streams() *Streams {
+	s := NewStreams(c.InputStream(), c.OutputStream(), c.ErrorStream())
+	s.SetLogLevel(c.LogLevel())
+
+	return &s
+}
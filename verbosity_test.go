@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEnableVerbosityFlags_ShorthandInSubcommand(t *testing.T) {
+	var vendor bool
+	root := &Cmd{Use: "app"}
+	sub := &Cmd{Use: "sub"}
+	sub.Flags().BoolVarP(&vendor, "vendor", "v", false, "vendor")
+	sub.SetRun(func(c *Cmd, args []string) error { return nil })
+	root.Add(sub)
+	root.EnableVerbosityFlags()
+
+	root.SetOutputStream(&bytes.Buffer{})
+	root.SetErrorStream(&bytes.Buffer{})
+	root.SetArgs([]string{"sub", "-v", "--verbose", "-q", "-q"})
+
+	if err := root.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !vendor {
+		t.Error("expected -v to set the flag of the subcommand")
+	}
+
+	if f := root.GlobalFlags().Lookup(verboseFlagName); f == nil || f.Short != "" {
+		t.Errorf("verbose flag = %+v, want it without a shorthand", f)
+	}
+
+	if f := root.GlobalFlags().Lookup(quietFlagName); f == nil || f.Short != "q" {
+		t.Errorf("quiet flag = %+v, want the q shorthand", f)
+	}
+
+	if got := sub.LogLevel(); got != LogError {
+		t.Errorf("LogLevel() = %v, want %v", got, LogError)
+	}
+}
+
+func TestLogLevel_ResetBetweenExecutions(t *testing.T) {
+	root := &Cmd{Use: "app"}
+	root.SetRun(func(c *Cmd, args []string) error { return nil })
+	root.EnableVerbosityFlags()
+	root.SetOutputStream(&bytes.Buffer{})
+	root.SetErrorStream(&bytes.Buffer{})
+
+	tests := []struct {
+		args []string
+		want LogLevel
+	}{
+		{args: []string{"-vv"}, want: LogDebug},
+		{args: []string{"-v"}, want: LogInfo},
+		{args: []string{}, want: LogWarn},
+		{args: []string{"-q"}, want: LogError},
+	}
+
+	for _, tt := range tests {
+		root.SetArgs(tt.args)
+		if err := root.Execute(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got := root.LogLevel(); got != tt.want {
+			t.Errorf("LogLevel() after %q = %v, want %v", tt.args, got, tt.want)
+		}
+	}
+}
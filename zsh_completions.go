@@ -0,0 +1,142 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+)
+
+// GenZshCompletion generates the zsh completion script for the root command
+// and writes it to w. When includeDesc is false the completions are shown
+// without their descriptions.
+func (c *Cmd) GenZshCompletion(w io.Writer, includeDesc bool) error {
+	name := c.Root().Name()
+	compCmd := ShellCompRequestCmd
+	if !includeDesc {
+		compCmd = ShellCompNoDescRequestCmd
+	}
+
+	_, err := fmt.Fprintf(w, zshCompletionTemplate, name, compCmd,
+		ShellCompDirectiveError, ShellCompDirectiveNoSpace, ShellCompDirectiveNoFileComp,
+		ShellCompDirectiveFilterFileExt, ShellCompDirectiveFilterDirs)
+
+	return err
+}
+
+const zshCompletionTemplate = `#compdef %[1]s
+compdef _%[1]s %[1]s
+
+# zsh completion for %-36[1]s -*- shell-script -*-
+
+__%[1]s_debug()
+{
+    local file="$BASH_COMP_DEBUG_FILE"
+    if [[ -n ${file} ]]; then
+        echo "$*" >> "${file}"
+    fi
+}
+
+_%[1]s()
+{
+    local shellCompDirectiveError=%[3]d
+    local shellCompDirectiveNoSpace=%[4]d
+    local shellCompDirectiveNoFileComp=%[5]d
+    local shellCompDirectiveFilterFileExt=%[6]d
+    local shellCompDirectiveFilterDirs=%[7]d
+
+    local lastParam lastChar flagPrefix requestComp out directive comp lastLine noSpace
+    local -a completions
+
+    # Completion is triggered from the cursor, the words after it are ignored
+    words=("${=words[1,CURRENT]}")
+    lastParam=${words[-1]}
+    lastChar=${lastParam[-1]}
+
+    # When completing a flag with an = the completions must be prefixed
+    # with the flag
+    setopt local_options BASH_REMATCH
+    if [[ "${lastParam}" =~ '-.*=' ]]; then
+        flagPrefix="-P ${BASH_REMATCH}"
+    fi
+
+    requestComp="${words[1]} %[2]s ${words[2,-1]}"
+    if [ "${lastChar}" = "" ]; then
+        # The last word is complete, ask for the completions of a new one
+        requestComp="${requestComp} \"\""
+    fi
+
+    __%[1]s_debug "Calling ${requestComp}"
+    out=$(eval ${requestComp} 2>/dev/null)
+
+    # The directive is the integer following the colon on the last line
+    while IFS='\n' read -r line; do
+        lastLine=${line}
+    done < <(printf "%%s\n" "${out[@]}")
+
+    if [ "${lastLine[1]}" = : ]; then
+        directive=${lastLine[2,-1]}
+        local suffix
+        (( suffix=${#lastLine}+2))
+        out=${out[1,-$suffix]}
+    else
+        directive=0
+    fi
+    __%[1]s_debug "The completion directive is: ${directive}"
+
+    if [ $((directive & shellCompDirectiveError)) -ne 0 ]; then
+        return
+    fi
+
+    local tab="$(printf '\t')"
+    while IFS='\n' read -r comp; do
+        if [ -n "$comp" ]; then
+            # _describe separates the description with a :, any : which is
+            # part of the completion must be escaped
+            comp=${comp//:/\\:}
+            comp=${comp//$tab/:}
+            completions+=${comp}
+        fi
+    done < <(printf "%%s\n" "${out[@]}")
+
+    if [ $((directive & shellCompDirectiveNoSpace)) -ne 0 ]; then
+        noSpace="-S ''"
+    fi
+
+    if [ $((directive & shellCompDirectiveFilterFileExt)) -ne 0 ]; then
+        # File extension filtering
+        local filteringCmd='_files'
+        for filter in ${completions[@]}; do
+            if [ ${filter[1]} != '*' ]; then
+                filter="\*.$filter"
+            fi
+            filteringCmd+=" -g $filter"
+        done
+        filteringCmd+=" ${flagPrefix}"
+
+        eval ${filteringCmd}
+    elif [ $((directive & shellCompDirectiveFilterDirs)) -ne 0 ]; then
+        # File completion for directories only
+        local subdir="${completions[1]}"
+        if [ -n "$subdir" ]; then
+            pushd "${subdir}" >/dev/null 2>&1
+        fi
+        _arguments '*:dirname:_files -/'" ${flagPrefix}"
+        local result=$?
+        if [ -n "$subdir" ]; then
+            popd >/dev/null 2>&1
+        fi
+        return $result
+    else
+        if eval _describe "completions" completions $flagPrefix $noSpace; then
+            return 0
+        elif [ $((directive & shellCompDirectiveNoFileComp)) -ne 0 ]; then
+            return 1
+        fi
+        _arguments '*:filename:_files'" ${flagPrefix}"
+    fi
+}
+
+# don't run the completion function when being sourced or evaluated
+if [ "$funcstack[1]" = "_%[1]s" ]; then
+    _%[1]s
+fi
+`